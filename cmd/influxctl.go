@@ -3,7 +3,6 @@ package main
 
 import (
 	"errors"
-	"fmt"
 	"os"
 
 	// frameworks
@@ -28,43 +27,37 @@ type CommandFunc func(client influxdb.Client, app *gopi.AppInstance) error
 
 var (
 	Commands = map[string]CommandFunc{
-		"Databases":      influxctl.ListDatabases,
-		"CreateDatabase": influxctl.CreateDatabase,
-		"DropDatabase":   influxctl.DropDatabase,
-		"Policies":       influxctl.ListRetentionPolicies,
-		"CreatePolicy":   influxctl.CreateRetentionPolicy,
-		"DropPolicy":     influxctl.DropRetentionPolicy,
-		"Series":         influxctl.ListSeries,
-		"Measurements":   influxctl.ListMeasurements,
-		"Query":          influxctl.Query,
-		"Import":         influxctl.Import,
+		"Databases":         influxctl.ListDatabases,
+		"CreateDatabase":    influxctl.CreateDatabase,
+		"DropDatabase":      influxctl.DropDatabase,
+		"Policies":          influxctl.ListRetentionPolicies,
+		"CreatePolicy":      influxctl.CreateRetentionPolicy,
+		"DropPolicy":        influxctl.DropRetentionPolicy,
+		"Series":            influxctl.ListSeries,
+		"Measurements":      influxctl.ListMeasurements,
+		"Query":             influxctl.Query,
+		"Import":            influxctl.Import,
+		"Schema":            influxctl.Schema,
+		"Delete":            influxctl.Delete,
+		"DropSeries":        influxctl.DropSeries,
+		"DropMeasurement":   influxctl.DropMeasurement,
+		"Queries":           influxctl.ListQueries,
+		"Kill":              influxctl.KillQuery,
+		"Shards":            influxctl.ListShards,
+		"Stats":             influxctl.Stats,
+		"DropShard":         influxctl.DropShard,
+		"ContinuousQueries": influxctl.ListContinuousQueries,
+		"CreateCQ":          influxctl.CreateContinuousQuery,
+		"DropCQ":            influxctl.DropContinuousQuery,
+		"Users":             influxctl.ListUsers,
+		"CreateUser":        influxctl.CreateUser,
+		"Grant":             influxctl.Grant,
+		"Revoke":            influxctl.Revoke,
 	}
 )
 
 ////////////////////////////////////////////////////////////////////////////////
 
-func GetOneArg(app *gopi.AppInstance, param1 string) (string, error) {
-	if args := app.AppFlags.Args(); len(args) < 2 {
-		return "", fmt.Errorf("Missing \"%v\" command-line argument", param1)
-	} else if len(args) > 2 {
-		return "", fmt.Errorf("Too many command-line arguments")
-	} else {
-		return args[1], nil
-	}
-}
-
-func GetPolicyValue(app *gopi.AppInstance) (*influxdb.RetentionPolicy, error) {
-	return &influxdb.RetentionPolicy{}, nil
-}
-
-func GetMeasurement(arg string) *influxdb.Measurement {
-	return &influxdb.Measurement{
-		Name: arg,
-	}
-}
-
-////////////////////////////////////////////////////////////////////////////////
-
 func MainTask(app *gopi.AppInstance, done chan<- struct{}) error {
 	// Call command
 	if args := app.AppFlags.Args(); len(args) < 1 {
@@ -92,6 +85,23 @@ func main() {
 	config.AppFlags.FlagString("db", "", "Database name")
 	config.AppFlags.FlagUint("limit", 1000, "Row limit")
 	config.AppFlags.FlagUint("offset", 0, "Row offset")
+	config.AppFlags.FlagString("tags", "", "Comma-separated tag values (key=value,...)")
+	config.AppFlags.FlagString("format", "csv", "Import format (csv, line)")
+	config.AppFlags.FlagString("precision", "ns", "Import timestamp precision for line protocol (ns, u, ms, s, m, h)")
+	config.AppFlags.FlagString("from", "", "Query start time (RFC3339 or YYYY-MM-DD)")
+	config.AppFlags.FlagString("to", "", "Query end time (RFC3339 or YYYY-MM-DD)")
+	config.AppFlags.FlagDuration("since", 0, "Query duration before now")
+	config.AppFlags.FlagString("into", "", "Continuous query target (policy.measurement)")
+	config.AppFlags.FlagString("fn", "mean", "Continuous query aggregate function")
+	config.AppFlags.FlagDuration("interval", 0, "Continuous query GROUP BY time interval")
+	config.AppFlags.FlagDuration("every", 0, "Continuous query RESAMPLE EVERY interval")
+	config.AppFlags.FlagDuration("for", 0, "Continuous query RESAMPLE FOR interval")
+	config.AppFlags.FlagString("password", "", "User password (read from stdin if not set)")
+	config.AppFlags.FlagBool("admin", false, "Create user with admin privileges")
+	config.AppFlags.FlagBool("dry-run", false, "Count the data which would be deleted without deleting it")
+	config.AppFlags.FlagBool("yes", false, "Delete data after counting it")
+	config.AppFlags.FlagString("module", "", "Statistics module (runtime, write, httpd, shard, ...)")
+	config.AppFlags.FlagString("write", "", "Database to write statistics to")
 
 	// Run Command-Line Tool
 	os.Exit(gopi.CommandLineTool(config, MainTask))
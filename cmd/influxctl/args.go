@@ -0,0 +1,108 @@
+package influxctl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// frameworks
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func GetOneArg(app *gopi.AppInstance, param1 string) (string, error) {
+	if args := app.AppFlags.Args(); len(args) < 2 {
+		return "", fmt.Errorf("Missing \"%v\" command-line argument", param1)
+	} else if len(args) > 2 {
+		return "", fmt.Errorf("Too many command-line arguments")
+	} else {
+		return args[1], nil
+	}
+}
+
+// GetArgs returns command-line arguments after the command name, and
+// returns an error if the number of arguments is different
+func GetArgs(app *gopi.AppInstance, params ...string) ([]string, error) {
+	if args := app.AppFlags.Args(); len(args) < len(params)+1 {
+		return nil, fmt.Errorf("Missing \"%v\" command-line argument", params[len(args)-1])
+	} else if len(args) > len(params)+1 {
+		return nil, fmt.Errorf("Too many command-line arguments")
+	} else {
+		return args[1:], nil
+	}
+}
+
+func GetPolicyValue(app *gopi.AppInstance) (*influxdb.RetentionPolicy, error) {
+	return &influxdb.RetentionPolicy{}, nil
+}
+
+func GetMeasurement(arg string) *influxdb.Measurement {
+	return &influxdb.Measurement{
+		Name: arg,
+	}
+}
+
+// GetTarget returns a measurement in the form policy.name, where the
+// name can be :MEASUREMENT to use the name of the source measurement
+func GetTarget(arg string) *influxdb.Measurement {
+	if parts := strings.SplitN(arg, ".", 2); len(parts) == 2 {
+		return &influxdb.Measurement{
+			Policy: parts[0],
+			Name:   parts[1],
+		}
+	} else {
+		return GetMeasurement(arg)
+	}
+}
+
+// GetTags returns tag keys and values from the -tags flag, which is
+// in the form key=value,key=value
+func GetTags(app *gopi.AppInstance) (map[string]string, error) {
+	tags := make(map[string]string)
+	if value, _ := app.AppFlags.GetString("tags"); value != "" {
+		for _, pair := range strings.Split(value, ",") {
+			if kv := strings.SplitN(pair, "=", 2); len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+				return nil, fmt.Errorf("Invalid -tags value: %v", pair)
+			} else {
+				tags[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+			}
+		}
+	}
+	return tags, nil
+}
+
+// GetTimeRange returns predicates for the -from, -to and -since flags. Times
+// are in RFC3339 format or a date in the form YYYY-MM-DD
+func GetTimeRange(app *gopi.AppInstance, precision string) ([]influxdb.Predicate, error) {
+	predicates := make([]influxdb.Predicate, 0, 2)
+	if value, _ := app.AppFlags.GetString("from"); value != "" {
+		if from, err := parseTime(value); err != nil {
+			return nil, fmt.Errorf("Invalid -from value: %v", value)
+		} else {
+			predicates = append(predicates, influxdb.TimeAfter(from, precision))
+		}
+	}
+	if value, _ := app.AppFlags.GetString("to"); value != "" {
+		if to, err := parseTime(value); err != nil {
+			return nil, fmt.Errorf("Invalid -to value: %v", value)
+		} else {
+			predicates = append(predicates, influxdb.TimeBefore(to, precision))
+		}
+	}
+	if since, _ := app.AppFlags.GetDuration("since"); since < 0 {
+		return nil, fmt.Errorf("Invalid -since value: %v", since)
+	} else if since > 0 {
+		predicates = append(predicates, influxdb.TimeSince(since))
+	}
+	return predicates, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	} else {
+		return time.Parse("2006-01-02", value)
+	}
+}
@@ -0,0 +1,110 @@
+package influxctl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	// frameworks
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/tablewriter"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+var (
+	// Aggregate functions which can be used for downsampling
+	aggregates = map[string]func(influxdb.Predicate) influxdb.Predicate{
+		"count":  influxdb.Count,
+		"first":  influxdb.First,
+		"last":   influxdb.Last,
+		"max":    influxdb.Max,
+		"mean":   influxdb.Mean,
+		"median": influxdb.Median,
+		"min":    influxdb.Min,
+		"mode":   influxdb.Mode,
+		"spread": influxdb.Spread,
+		"stddev": influxdb.Stddev,
+		"sum":    influxdb.Sum,
+	}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func ListContinuousQueries(client influxdb.Client, app *gopi.AppInstance) error {
+	// Set database if the flag is set, otherwise list for all databases
+	if db, _ := app.AppFlags.GetString("db"); db != "" {
+		if err := client.SetDatabase(db); err != nil {
+			return err
+		}
+	}
+
+	// Return a table of continuous queries
+	if queries, err := client.ContinuousQueries(); err != nil {
+		return err
+	} else {
+		result := &influxdb.Result{
+			Name:    "continuous queries",
+			Columns: []string{"database", "name", "every", "for", "query"},
+			Values:  make([][]interface{}, len(queries)),
+		}
+		for i, cq := range queries {
+			result.Values[i] = []interface{}{cq.Database, cq.Name, cq.Every, cq.For, cq.Query}
+		}
+		return tablewriter.RenderASCII(result, os.Stdout)
+	}
+}
+
+// CreateContinuousQuery creates a continuous query which downsamples
+// a measurement into another measurement or retention policy
+func CreateContinuousQuery(client influxdb.Client, app *gopi.AppInstance) error {
+	// Get flags
+	db, _ := app.AppFlags.GetString("db")
+	into, _ := app.AppFlags.GetString("into")
+	fn, _ := app.AppFlags.GetString("fn")
+	interval, _ := app.AppFlags.GetDuration("interval")
+	every, _ := app.AppFlags.GetDuration("every")
+	for_, _ := app.AppFlags.GetDuration("for")
+
+	if db == "" {
+		return errors.New("-db flag required")
+	} else if into == "" {
+		return errors.New("-into flag required")
+	} else if interval <= 0 {
+		return errors.New("-interval flag required")
+	} else if aggregate, exists := aggregates[strings.ToLower(fn)]; exists == false {
+		return fmt.Errorf("Invalid -fn value: %v", fn)
+	} else if args, err := GetArgs(app, "CQ Name", "Measurement"); err != nil {
+		return err
+	} else if err := client.SetDatabase(db); err != nil {
+		return err
+	} else {
+		q := influxdb.Select(GetMeasurement(args[1])).
+			Columns(aggregate(influxdb.Field("*"))).
+			Into(GetTarget(into)).
+			GroupBy(influxdb.Time(interval, 0), influxdb.Tag("*"))
+		if err := client.CreateContinuousQuery(args[0], q, every, for_); err != nil {
+			return err
+		}
+	}
+
+	return ListContinuousQueries(client, app)
+}
+
+func DropContinuousQuery(client influxdb.Client, app *gopi.AppInstance) error {
+	// Get database flag and continuous query name
+	db, _ := app.AppFlags.GetString("db")
+	if db == "" {
+		return errors.New("-db flag required")
+	} else if name, err := GetOneArg(app, "CQ Name"); err != nil {
+		return err
+	} else if err := client.SetDatabase(db); err != nil {
+		return err
+	} else if err := client.DropContinuousQuery(name); err != nil {
+		return err
+	} else {
+		return ListContinuousQueries(client, app)
+	}
+}
@@ -0,0 +1,139 @@
+package influxctl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	// frameworks
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/tablewriter"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Delete removes points from a measurement which match the -tags flag
+// and the -from, -to and -since flags
+func Delete(client influxdb.Client, app *gopi.AppInstance) error {
+	db, _ := app.AppFlags.GetString("db")
+	if db == "" {
+		return errors.New("-db flag required")
+	} else if err := client.SetDatabase(db); err != nil {
+		return err
+	} else if name, err := GetOneArg(app, "Measurement"); err != nil {
+		return err
+	} else if where, err := GetTagFilter(app); err != nil {
+		return err
+	} else if times, err := GetTimeRange(app, client.Precision()); err != nil {
+		return err
+	} else {
+		measurement := GetMeasurement(name)
+		where = append(where, times...)
+		if len(where) == 0 {
+			return errors.New("-tags, -from, -to or -since flag required")
+		}
+		return execute(client, app, measurement, where, true, influxdb.Delete(measurement).Filter(where...))
+	}
+}
+
+// DropSeries removes series from a measurement which match the
+// -tags flag
+func DropSeries(client influxdb.Client, app *gopi.AppInstance) error {
+	db, _ := app.AppFlags.GetString("db")
+	if db == "" {
+		return errors.New("-db flag required")
+	} else if err := client.SetDatabase(db); err != nil {
+		return err
+	} else if name, err := GetOneArg(app, "Measurement"); err != nil {
+		return err
+	} else if where, err := GetTagFilter(app); err != nil {
+		return err
+	} else if len(where) == 0 {
+		return errors.New("-tags flag required")
+	} else {
+		measurement := GetMeasurement(name)
+		return execute(client, app, measurement, where, false, influxdb.DropSeries(measurement).Filter(where...))
+	}
+}
+
+// DropMeasurement removes a measurement and all its series
+func DropMeasurement(client influxdb.Client, app *gopi.AppInstance) error {
+	db, _ := app.AppFlags.GetString("db")
+	if db == "" {
+		return errors.New("-db flag required")
+	} else if err := client.SetDatabase(db); err != nil {
+		return err
+	} else if name, err := GetOneArg(app, "Measurement"); err != nil {
+		return err
+	} else {
+		return execute(client, app, GetMeasurement(name), nil, true, influxdb.DropMeasurement(name))
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetTagFilter returns predicates for the -tags flag
+func GetTagFilter(app *gopi.AppInstance) ([]influxdb.Predicate, error) {
+	if tags, err := GetTags(app); err != nil {
+		return nil, err
+	} else {
+		return tagFilter(tags), nil
+	}
+}
+
+// tagFilter returns a predicate for each tag, ordered by key
+func tagFilter(tags map[string]string) []influxdb.Predicate {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	where := make([]influxdb.Predicate, len(keys))
+	for i, k := range keys {
+		where[i] = influxdb.TagEquals(k, tags[k])
+	}
+	return where
+}
+
+// execute prints the number of series, and the number of values for
+// each field when points is true, which match the predicates. The query
+// is only executed when the -yes flag is set and -dry-run is not
+func execute(client influxdb.Client, app *gopi.AppInstance, measurement *influxdb.Measurement, where []influxdb.Predicate, points bool, query influxdb.Query) error {
+	yes, _ := app.AppFlags.GetBool("yes")
+	dryrun, _ := app.AppFlags.GetBool("dry-run")
+
+	// Count series
+	if series, err := client.Cardinality(influxdb.ShowSeriesCardinality(true).Measurement(measurement).Filter(where...)); err != nil {
+		return err
+	} else {
+		fmt.Printf("%v: %v series\n", measurement, series)
+	}
+
+	// Count values for each field
+	if points {
+		q := influxdb.Select(measurement).Columns(influxdb.Count(influxdb.Field("*"))).Filter(where...)
+		if r, err := client.Do(q); err == influxdb.ErrEmptyResponse {
+			fmt.Printf("%v: no points\n", measurement)
+		} else if err != nil {
+			return err
+		} else {
+			for _, dataset := range r {
+				tablewriter.RenderASCII(dataset, os.Stdout)
+			}
+		}
+	}
+
+	// Execute the query
+	fmt.Println(query)
+	if dryrun || yes == false {
+		fmt.Println("Dry run: use the -yes flag to execute")
+		return nil
+	} else if _, err := client.Do(query); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	} else {
+		fmt.Println("Done")
+		return nil
+	}
+}
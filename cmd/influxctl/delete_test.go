@@ -0,0 +1,26 @@
+package influxctl
+
+import (
+	"testing"
+
+	"github.com/djthorpe/influxdb"
+)
+
+func TestDelete_001(t *testing.T) {
+	tests := []struct {
+		tags     map[string]string
+		expected string
+	}{
+		{map[string]string{"host": "x"}, "DELETE FROM m WHERE host = 'x'"},
+		{map[string]string{"region": "eu", "host": "it's"}, "DELETE FROM m WHERE host = 'it\\'s' AND region = 'eu'"},
+		{map[string]string{"host name": "x y"}, "DELETE FROM m WHERE \"host name\" = 'x y'"},
+	}
+	for _, test := range tests {
+		if query := influxdb.Delete(GetMeasurement("m")).Filter(tagFilter(test.tags)...); query.String() != test.expected {
+			t.Errorf("Expected %v, got %v", test.expected, query)
+		}
+	}
+	if query := influxdb.DropSeries(GetMeasurement("m")).Filter(tagFilter(map[string]string{"host": "x"})...); query.String() != "DROP SERIES FROM m WHERE host = 'x'" {
+		t.Errorf("Unexpected query: %v", query)
+	}
+}
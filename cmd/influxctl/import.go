@@ -1,33 +1,193 @@
 package influxctl
 
 import (
-
-	// frameworks
+	"encoding/csv"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
 
+	// frameworks
 	gopi "github.com/djthorpe/gopi"
 	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/lineprotocol"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	// The number of rows written in each batch
+	IMPORT_BATCH_SIZE = 5000
+
+	// The name of the column which contains the timestamp
+	IMPORT_TIME_COLUMN = "time"
+
+	// Import formats
+	IMPORT_FORMAT_CSV  = "csv"
+	IMPORT_FORMAT_LINE = "line"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// Import reads data from standard input and writes it to the database.
+// With -format csv the first row contains the field names, and a "time"
+// column (in RFC3339 format) is used for the timestamp if present. Tags are
+// set with the -tags flag. With -format line the data is in line protocol
+// with timestamps in nanoseconds (or the -precision flag), and no measurement
+// argument is required. Lines which cannot be parsed are skipped, and are
+// reported together once the other lines have been written
 func Import(client influxdb.Client, app *gopi.AppInstance) error {
 	// Get flags
 	db, _ := app.AppFlags.GetString("db")
+	format, _ := app.AppFlags.GetString("format")
+	precision, _ := app.AppFlags.GetString("precision")
 
 	// Select database, retrieve measurement name
 	if db == "" {
 		return errors.New("-db flag required")
 	} else if err := client.SetDatabase(db); err != nil {
 		return err
+	} else if format == IMPORT_FORMAT_LINE {
+		if len(app.AppFlags.Args()) > 1 {
+			return errors.New("Too many command-line arguments")
+		}
+		return importLineProtocol(client, precision, os.Stdin)
+	} else if format != IMPORT_FORMAT_CSV && format != "" {
+		return fmt.Errorf("Invalid -format value: %v", format)
 	} else if measurement, err := GetOneArg(app, "Measurement"); err != nil {
 		return err
-	} else if dataset, err := client.NewDataset(measurement, []string{"tag1", "tag2"}, []string{"field1", "field2"}); err != nil {
+	} else if tags, err := GetTags(app); err != nil {
 		return err
 	} else {
-		if err := client.Write(dataset); err != nil {
+		return importCSV(client, measurement, tags, os.Stdin)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func importCSV(client influxdb.Client, measurement string, tags map[string]string, r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	// Read the header row and determine the time column
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(header))
+	timeColumn := -1
+	for i, column := range header {
+		if column == IMPORT_TIME_COLUMN {
+			timeColumn = i
+		} else {
+			fields = append(fields, column)
+		}
+	}
+
+	// Create dataset
+	tagKeys := make([]string, 0, len(tags))
+	for k := range tags {
+		tagKeys = append(tagKeys, k)
+	}
+	dataset, err := client.NewDataset(measurement, tagKeys, fields)
+	if err != nil {
+		return err
+	}
+	for k, v := range tags {
+		dataset.SetTag(k, v)
+	}
+
+	// Read rows and write in batches
+	values := make([]influxdb.Value, 0, len(fields))
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
 			return err
 		}
+		values = values[:0]
+		ts := time.Time{}
+		for i, cell := range row {
+			if i == timeColumn {
+				if ts, err = time.Parse(time.RFC3339Nano, cell); err != nil {
+					return fmt.Errorf("Line %v: %v", line, err)
+				}
+			} else {
+				values = append(values, importValue(cell))
+			}
+		}
+		if ts.IsZero() {
+			err = dataset.AddValues(values...)
+		} else {
+			err = dataset.AddValuesForTimestamp(ts, values...)
+		}
+		if err != nil {
+			return fmt.Errorf("Line %v: %v", line, err)
+		}
+		if dataset.Len() >= IMPORT_BATCH_SIZE {
+			if err := client.Write(dataset); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Write remaining rows
+	return client.Write(dataset)
+}
+
+// importLineProtocol writes points with the precision of the timestamps
+// in the input, so that they are not truncated to the precision of the
+// client
+func importLineProtocol(client influxdb.Client, precision string, r io.Reader) error {
+	decoder := lineprotocol.NewDecoder(r)
+	if precision == "" {
+		precision = influxdb.PRECISION_NANO
+	}
+	if err := decoder.SetPrecision(precision); err != nil {
+		return fmt.Errorf("Invalid -precision value: %v", precision)
+	}
+	defer client.SetPrecision(client.Precision())
+	if err := client.SetPrecision(precision); err != nil {
+		return err
+	}
+
+	errs := make(lineprotocol.SyntaxErrors, 0)
+	for {
+		datasets, err := decoder.DecodeDatasets(client, IMPORT_BATCH_SIZE)
+		if err == io.EOF {
+			break
+		} else if err_, ok := err.(lineprotocol.SyntaxErrors); ok {
+			errs = append(errs, err_...)
+		} else if err != nil {
+			return err
+		}
+		for _, dataset := range datasets {
+			if err := client.Write(dataset); err != nil {
+				return err
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
 	}
 	return nil
 }
+
+// importValue returns a value as integer, float, boolean or string, or
+// nil if the cell is empty
+func importValue(cell string) influxdb.Value {
+	if cell == "" {
+		return nil
+	} else if v, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		return v
+	} else if v, err := strconv.ParseFloat(cell, 64); err == nil {
+		return v
+	} else if v, err := strconv.ParseBool(cell); err == nil {
+		return v
+	} else {
+		return cell
+	}
+}
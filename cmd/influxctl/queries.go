@@ -0,0 +1,43 @@
+package influxctl
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	// frameworks
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/tablewriter"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func ListQueries(client influxdb.Client, app *gopi.AppInstance) error {
+	// Return a table of running queries
+	if queries, err := client.Queries(); err != nil {
+		return err
+	} else {
+		result := &influxdb.Result{
+			Name:    "queries",
+			Columns: []string{"qid", "database", "duration", "status", "query"},
+			Values:  make([][]interface{}, len(queries)),
+		}
+		for i, q := range queries {
+			result.Values[i] = []interface{}{q.Id, q.Database, q.Duration, q.Status, q.Query}
+		}
+		return tablewriter.RenderASCII(result, os.Stdout)
+	}
+}
+
+func KillQuery(client influxdb.Client, app *gopi.AppInstance) error {
+	if arg, err := GetOneArg(app, "Query Id"); err != nil {
+		return err
+	} else if id, err := strconv.ParseUint(arg, 10, 64); err != nil {
+		return fmt.Errorf("Invalid query id: %v", arg)
+	} else if err := client.KillQuery(id); err != nil {
+		return err
+	} else {
+		return ListQueries(client, app)
+	}
+}
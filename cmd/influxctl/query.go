@@ -25,8 +25,10 @@ func Query(client influxdb.Client, app *gopi.AppInstance) error {
 		return err
 	} else if measurement, err := GetOneArg(app, "Measurement"); err != nil {
 		return err
+	} else if where, err := GetTimeRange(app, client.Precision()); err != nil {
+		return err
 	} else {
-		q := influxdb.Select(GetMeasurement(measurement)).OffsetLimit(offset, limit)
+		q := influxdb.Select(GetMeasurement(measurement)).Filter(where...).OffsetLimit(offset, limit)
 		if r, err := client.Do(q); err != nil {
 			return err
 		} else {
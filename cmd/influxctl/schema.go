@@ -3,6 +3,7 @@ package influxctl
 import (
 	"errors"
 	"os"
+	"sort"
 
 	// frameworks
 	gopi "github.com/djthorpe/gopi"
@@ -81,7 +82,6 @@ func DropRetentionPolicy(client influxdb.Client, app *gopi.AppInstance) error {
 	} else {
 		return ListRetentionPolicies(client, app)
 	}
-	return nil
 }
 
 func ListRetentionPolicies(client influxdb.Client, app *gopi.AppInstance) error {
@@ -146,3 +146,46 @@ func ListMeasurements(client influxdb.Client, app *gopi.AppInstance) error {
 		return nil
 	}
 }
+
+// Schema prints the tag keys and field keys with types for each
+// measurement in the database
+func Schema(client influxdb.Client, app *gopi.AppInstance) error {
+	// Set database
+	db, _ := app.AppFlags.GetString("db")
+	if db == "" {
+		return errors.New("-db flag required")
+	} else if err := client.SetDatabase(db); err != nil {
+		return err
+	}
+
+	// Return a table for each measurement
+	schema, err := client.Schema()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result := &influxdb.Result{
+			Name:    name,
+			Columns: []string{"key", "kind", "type"},
+			Values:  make([][]interface{}, 0, len(schema[name].Tags)+len(schema[name].Fields)),
+		}
+		for _, tag := range schema[name].Tags {
+			result.Values = append(result.Values, []interface{}{tag, "tag", "string"})
+		}
+		fields := make([]string, 0, len(schema[name].Fields))
+		for field := range schema[name].Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			result.Values = append(result.Values, []interface{}{field, "field", schema[name].Fields[field]})
+		}
+		tablewriter.RenderASCII(result, os.Stdout)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package influxctl
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	// frameworks
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/tablewriter"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// shardPolicy summarises the shards for a retention policy
+type shardPolicy struct {
+	database, policy string
+	retention        *influxdb.RetentionPolicy
+	shards           uint
+	oldest, newest   time.Time
+	expiry           time.Time
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func ListShards(client influxdb.Client, app *gopi.AppInstance) error {
+	db, _ := app.AppFlags.GetString("db")
+	shards, err := client.Shards()
+	if err != nil {
+		return err
+	}
+
+	// Summarise shards by database and retention policy
+	keys := make([]string, 0)
+	policies := make(map[string]*shardPolicy)
+	for _, shard := range shards {
+		if db != "" && shard.Database != db {
+			continue
+		}
+		key := shard.Database + "." + shard.Policy
+		summary, exists := policies[key]
+		if exists == false {
+			summary = &shardPolicy{database: shard.Database, policy: shard.Policy}
+			policies[key] = summary
+			keys = append(keys, key)
+		}
+		summary.shards++
+		if summary.oldest.IsZero() || shard.Start.Before(summary.oldest) {
+			summary.oldest = shard.Start
+		}
+		if shard.End.After(summary.newest) {
+			summary.newest = shard.End
+		}
+		if summary.expiry.IsZero() || shard.Expiry.Before(summary.expiry) {
+			summary.expiry = shard.Expiry
+		}
+	}
+	sort.Strings(keys)
+
+	// Add retention policies for each database
+	databases := make(map[string]map[string]*influxdb.RetentionPolicy)
+	for _, key := range keys {
+		summary := policies[key]
+		if _, exists := databases[summary.database]; exists == false {
+			if err := client.SetDatabase(summary.database); err != nil {
+				return err
+			} else if policies_, err := client.RetentionPolicies(); err != nil {
+				return err
+			} else {
+				databases[summary.database] = policies_
+			}
+		}
+		summary.retention = databases[summary.database][summary.policy]
+	}
+
+	// Return a table of shards by retention policy
+	result := &influxdb.Result{
+		Name:    "shards",
+		Columns: []string{"database", "policy", "duration", "shard_duration", "shards", "oldest", "newest", "next_expiry"},
+		Values:  make([][]interface{}, len(keys)),
+	}
+	for i, key := range keys {
+		summary := policies[key]
+		duration, shard_duration := "", ""
+		if summary.retention != nil {
+			duration, shard_duration = fmt.Sprint(summary.retention.Duration), fmt.Sprint(summary.retention.ShardGroupDuration)
+			if summary.retention.Duration == 0 {
+				duration = "INF"
+			}
+		}
+		expiry := ""
+		if summary.retention != nil && summary.retention.Duration != 0 {
+			expiry = summary.expiry.Format(time.RFC3339)
+		}
+		result.Values[i] = []interface{}{summary.database, summary.policy, duration, shard_duration, summary.shards, summary.oldest.Format(time.RFC3339), summary.newest.Format(time.RFC3339), expiry}
+	}
+	return tablewriter.RenderASCII(result, os.Stdout)
+}
+
+func DropShard(client influxdb.Client, app *gopi.AppInstance) error {
+	if arg, err := GetOneArg(app, "Shard Id"); err != nil {
+		return err
+	} else if id, err := strconv.ParseUint(arg, 10, 64); err != nil {
+		return fmt.Errorf("Invalid shard id: %v", arg)
+	} else if err := client.DropShard(id); err != nil {
+		return err
+	} else {
+		return ListShards(client, app)
+	}
+}
@@ -0,0 +1,140 @@
+package influxctl
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	// frameworks
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/tablewriter"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func Stats(client influxdb.Client, app *gopi.AppInstance) error {
+	module, _ := app.AppFlags.GetString("module")
+	results, err := client.Do(influxdb.ShowStats(module))
+	if err != nil {
+		return err
+	}
+	stats, err := results.ParseStats()
+	if err != nil {
+		return err
+	}
+
+	// Write the snapshot to a monitoring database
+	if db, _ := app.AppFlags.GetString("write"); db != "" {
+		if err := client.SetDatabase(db); err != nil {
+			return err
+		} else if err := WriteStats(client, results, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// Return tables of server and shard statistics
+	if stats.Runtime != nil || stats.Write != nil || len(stats.HTTPD) > 0 {
+		if err := tablewriter.RenderASCII(serverStats(stats), os.Stdout); err != nil {
+			return err
+		}
+	}
+	if len(stats.Shards) > 0 {
+		if err := tablewriter.RenderASCII(shardStats(stats), os.Stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteStats writes each series in the response to SHOW STATS as a
+// point with the series tags, at time ts
+func WriteStats(client influxdb.Client, results influxdb.Results, ts time.Time) error {
+	for _, result := range results {
+		if len(result.Columns) == 0 {
+			continue
+		}
+		tags := make([]string, 0, len(result.Tags))
+		for k := range result.Tags {
+			tags = append(tags, k)
+		}
+		sort.Strings(tags)
+		dataset, err := client.NewDataset(result.Name, tags, result.Columns)
+		if err != nil {
+			return err
+		}
+		for k, v := range result.Tags {
+			dataset.SetTag(k, v)
+		}
+		for _, row := range result.Values {
+			values := make([]influxdb.Value, len(row))
+			for i := range row {
+				values[i] = row[i]
+			}
+			if err := dataset.AddValuesForTimestamp(ts, values...); err != nil {
+				return err
+			}
+		}
+		if err := client.Write(dataset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func serverStats(stats *influxdb.Stats) *influxdb.Result {
+	result := &influxdb.Result{
+		Name:    "server",
+		Columns: []string{"module", "stat", "value"},
+		Values:  make([][]interface{}, 0),
+	}
+	if stats.Runtime != nil {
+		result.Values = append(result.Values,
+			[]interface{}{"runtime", "heap_alloc", stats.Runtime.HeapAlloc},
+			[]interface{}{"runtime", "sys", stats.Runtime.Sys},
+			[]interface{}{"runtime", "goroutines", stats.Runtime.NumGoroutine},
+			[]interface{}{"runtime", "gc", stats.Runtime.NumGC},
+			[]interface{}{"runtime", "gc_pause", stats.Runtime.PauseTotal},
+		)
+	}
+	if stats.Write != nil {
+		result.Values = append(result.Values,
+			[]interface{}{"write", "points_ok", stats.Write.WriteOk},
+			[]interface{}{"write", "points_error", stats.Write.WriteError},
+			[]interface{}{"write", "points_dropped", stats.Write.WriteDrop},
+			[]interface{}{"write", "timeouts", stats.Write.WriteTimeout},
+		)
+	}
+	for _, httpd := range stats.HTTPD {
+		result.Values = append(result.Values,
+			[]interface{}{"httpd " + httpd.Bind, "requests", httpd.Req},
+			[]interface{}{"httpd " + httpd.Bind, "queries", httpd.QueryReq},
+			[]interface{}{"httpd " + httpd.Bind, "writes", httpd.WriteReq},
+			[]interface{}{"httpd " + httpd.Bind, "client_errors", httpd.ClientError},
+			[]interface{}{"httpd " + httpd.Bind, "server_errors", httpd.ServerError},
+		)
+	}
+	return result
+}
+
+func shardStats(stats *influxdb.Stats) *influxdb.Result {
+	tsm1 := make(map[uint64]*influxdb.TSM1Stats, len(stats.TSM1))
+	for _, shard := range stats.TSM1 {
+		tsm1[shard.Id] = shard
+	}
+	result := &influxdb.Result{
+		Name:    "shards",
+		Columns: []string{"id", "database", "policy", "disk_bytes", "cache_bytes", "wal_bytes", "files", "series_created", "points_ok", "points_error"},
+		Values:  make([][]interface{}, len(stats.Shards)),
+	}
+	for i, shard := range stats.Shards {
+		cache, wal, files := int64(0), int64(0), int64(0)
+		if engine, exists := tsm1[shard.Id]; exists {
+			cache, wal, files = engine.CacheMemBytes, engine.WALCurrentBytes+engine.WALOldBytes, engine.FileStoreFiles
+		}
+		result.Values[i] = []interface{}{shard.Id, shard.Database, shard.Policy, shard.DiskBytes, cache, wal, files, shard.SeriesCreate, shard.WritePointsOk, shard.WritePointsErr}
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package influxctl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	// frameworks
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/tablewriter"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func ListUsers(client influxdb.Client, app *gopi.AppInstance) error {
+	// Return a table of users
+	if users, err := client.Users(); err != nil {
+		return err
+	} else {
+		result := &influxdb.Result{
+			Name:    "users",
+			Columns: []string{"user", "admin"},
+			Values:  make([][]interface{}, len(users)),
+		}
+		for i, user := range users {
+			result.Values[i] = []interface{}{user.Name, user.Admin}
+		}
+		return tablewriter.RenderASCII(result, os.Stdout)
+	}
+}
+
+// CreateUser creates a user with the password from the -password flag,
+// or read from standard input when the flag is not set
+func CreateUser(client influxdb.Client, app *gopi.AppInstance) error {
+	admin, _ := app.AppFlags.GetBool("admin")
+	if name, err := GetOneArg(app, "User Name"); err != nil {
+		return err
+	} else if password, err := GetPassword(app); err != nil {
+		return err
+	} else if err := client.CreateUser(name, password, admin); err != nil {
+		return err
+	}
+
+	return ListUsers(client, app)
+}
+
+// Grant a privilege to a user on the database set by the -db flag, or
+// admin privileges when the privilege is "all" and there is no -db flag
+func Grant(client influxdb.Client, app *gopi.AppInstance) error {
+	db, _ := app.AppFlags.GetString("db")
+	if args, err := GetArgs(app, "User Name", "Privilege"); err != nil {
+		return err
+	} else if privilege, err := GetPrivilege(args[1]); err != nil {
+		return err
+	} else if err := client.Grant(args[0], db, privilege); err != nil {
+		return err
+	} else {
+		return ListGrants(client, args[0])
+	}
+}
+
+// Revoke a privilege from a user on the database set by the -db flag, or
+// admin privileges when the privilege is "all" and there is no -db flag
+func Revoke(client influxdb.Client, app *gopi.AppInstance) error {
+	db, _ := app.AppFlags.GetString("db")
+	if args, err := GetArgs(app, "User Name", "Privilege"); err != nil {
+		return err
+	} else if privilege, err := GetPrivilege(args[1]); err != nil {
+		return err
+	} else if err := client.Revoke(args[0], db, privilege); err != nil {
+		return err
+	} else {
+		return ListGrants(client, args[0])
+	}
+}
+
+func ListGrants(client influxdb.Client, name string) error {
+	// Return a table of privileges for a user
+	if privileges, err := client.Grants(name); err != nil {
+		return err
+	} else {
+		result := &influxdb.Result{
+			Name:    name,
+			Columns: []string{"database", "privilege"},
+			Values:  make([][]interface{}, len(privileges)),
+		}
+		for i, privilege := range privileges {
+			result.Values[i] = []interface{}{privilege.Database, privilege.Privilege}
+		}
+		return tablewriter.RenderASCII(result, os.Stdout)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetPrivilege returns a privilege from a command-line argument, which
+// is one of read, write or all
+func GetPrivilege(arg string) (string, error) {
+	switch strings.ToLower(arg) {
+	case "read":
+		return influxdb.PRIVILEGE_READ, nil
+	case "write":
+		return influxdb.PRIVILEGE_WRITE, nil
+	case "all":
+		return influxdb.PRIVILEGE_ALL, nil
+	default:
+		return "", fmt.Errorf("Invalid privilege: %v (expected read, write or all)", arg)
+	}
+}
+
+// GetPassword returns the -password flag, or reads a line from
+// standard input
+func GetPassword(app *gopi.AppInstance) (string, error) {
+	if password, _ := app.AppFlags.GetString("password"); password != "" {
+		return password, nil
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	if line, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil && line == "" {
+		return "", errors.New("Missing password")
+	} else if password := strings.TrimRight(line, "\r\n"); password == "" {
+		return "", errors.New("Missing password")
+	} else {
+		return password, nil
+	}
+}
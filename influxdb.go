@@ -37,6 +37,30 @@ const (
 	PRECISION_DEFAULT string = PRECISION_MILLI
 )
 
+const (
+	// Privilege defines the access a user has to a database
+	PRIVILEGE_NONE  string = "NO PRIVILEGES"
+	PRIVILEGE_READ  string = "READ"
+	PRIVILEGE_WRITE string = "WRITE"
+	PRIVILEGE_ALL   string = "ALL PRIVILEGES"
+)
+
+const (
+	// MEASUREMENT_BACKREFERENCE is used as the name of the target measurement
+	// in SELECT INTO queries to write to a measurement with the same name
+	// as the source
+	MEASUREMENT_BACKREFERENCE string = ":MEASUREMENT"
+)
+
+const (
+	// Fill defines the value reported for time intervals with no
+	// data when grouping by time. A numeric value can also be used
+	FILL_NULL     string = "null"
+	FILL_NONE     string = "none"
+	FILL_PREVIOUS string = "previous"
+	FILL_LINEAR   string = "linear"
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // GLOBAL VARIABLES
 
@@ -61,6 +85,21 @@ var (
 
 	// ErrNotSupported is returned if a feature is not yet supported
 	ErrNotSupported = errors.New("Not supported")
+
+	// ErrDatabaseNotFound is returned when a statement refers to a database
+	// which does not exist
+	ErrDatabaseNotFound = errors.New("Database not found")
+
+	// ErrAuthorization is returned when the credentials are invalid or the
+	// user does not have the privileges for a statement
+	ErrAuthorization = errors.New("Authorization failed")
+
+	// ErrTimeout is returned when a request to the server times out
+	ErrTimeout = errors.New("Timeout")
+
+	// ErrOverloaded is returned when the server is unable to accept a
+	// request because of load
+	ErrOverloaded = errors.New("Server overloaded")
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -74,16 +113,240 @@ type RetentionPolicy struct {
 	Default            bool
 }
 
+// ContinuousQuery defines a query which is run periodically by the
+// server, usually to downsample data
+type ContinuousQuery struct {
+	Name     string
+	Database string
+	Query    string
+	Every    time.Duration
+	For      time.Duration
+}
+
+// Schema defines the tag keys and field keys for the measurements
+// in a database
+type Schema map[string]*MeasurementSchema
+
+// MeasurementSchema defines the tag keys for a measurement, and the
+// type of each field key (float, integer, string or boolean)
+type MeasurementSchema struct {
+	Tags   []string
+	Fields map[string]string
+}
+
+// Shard defines a shard, which stores the data for a retention policy
+// within a period of time
+type Shard struct {
+	Id         uint64
+	Database   string
+	Policy     string
+	ShardGroup uint64
+	Start      time.Time
+	End        time.Time
+	Expiry     time.Time
+	Owners     []uint64
+}
+
+// ShardGroup defines a group of shards for a retention policy which
+// covers a period of time
+type ShardGroup struct {
+	Id       uint64
+	Database string
+	Policy   string
+	Start    time.Time
+	End      time.Time
+	Expiry   time.Time
+}
+
+// QueryInfo defines a query which is running on the server
+type QueryInfo struct {
+	Id       uint64
+	Query    string
+	Database string
+	Duration time.Duration
+	Status   string
+}
+
+// User defines a database user
+type User struct {
+	Name  string
+	Admin bool
+}
+
+// Privilege defines the access a user has to a database, which is
+// one of the PRIVILEGE_ constants
+type Privilege struct {
+	Database  string
+	Privilege string
+}
+
+// Stats is a snapshot of the server statistics returned by SHOW STATS
+type Stats struct {
+	Runtime *RuntimeStats
+	Write   *WriteStats
+	HTTPD   []*HTTPDStats
+	Shards  []*ShardStats
+	TSM1    []*TSM1Stats
+}
+
+// RuntimeStats defines memory and goroutine statistics for the server process
+type RuntimeStats struct {
+	Alloc        int64
+	TotalAlloc   int64
+	Sys          int64
+	HeapAlloc    int64
+	HeapInUse    int64
+	HeapObjects  int64
+	Mallocs      int64
+	Frees        int64
+	NumGC        int64
+	NumGoroutine int64
+	PauseTotal   time.Duration
+}
+
+// WriteStats defines statistics for points written to the server
+type WriteStats struct {
+	Req          int64
+	PointReq     int64
+	WriteOk      int64
+	WriteError   int64
+	WriteDrop    int64
+	WriteTimeout int64
+	SubWriteOk   int64
+	SubWriteDrop int64
+}
+
+// HTTPDStats defines statistics for the HTTP service on a bind address
+type HTTPDStats struct {
+	Bind                 string
+	Req                  int64
+	ReqActive            int64
+	QueryReq             int64
+	WriteReq             int64
+	PingReq              int64
+	ClientError          int64
+	ServerError          int64
+	AuthFail             int64
+	PointsWrittenOK      int64
+	PointsWrittenFail    int64
+	PointsWrittenDropped int64
+	QueryRespBytes       int64
+	WriteReqBytes        int64
+	ReqDuration          time.Duration
+	QueryReqDuration     time.Duration
+	WriteReqDuration     time.Duration
+}
+
+// ShardStats defines statistics for a shard
+type ShardStats struct {
+	Id                 uint64
+	Database           string
+	Policy             string
+	Engine             string
+	Path               string
+	DiskBytes          int64
+	SeriesCreate       int64
+	FieldsCreate       int64
+	WriteReq           int64
+	WriteReqOk         int64
+	WriteReqErr        int64
+	WritePointsOk      int64
+	WritePointsErr     int64
+	WritePointsDropped int64
+	WriteBytes         int64
+}
+
+// TSM1Stats defines statistics for the cache, files and write-ahead log
+// of the storage engine for a shard
+type TSM1Stats struct {
+	Id                 uint64
+	Database           string
+	Policy             string
+	CacheMemBytes      int64
+	CacheDiskBytes     int64
+	CacheAge           time.Duration
+	CacheWriteOk       int64
+	CacheWriteErr      int64
+	CacheWriteDropped  int64
+	CacheCompactions   int64
+	FileStoreDiskBytes int64
+	FileStoreFiles     int64
+	WALCurrentBytes    int64
+	WALOldBytes        int64
+	WALWriteOk         int64
+	WALWriteErr        int64
+}
+
+// Diagnostics defines the build, runtime and system information
+// returned by SHOW DIAGNOSTICS
+type Diagnostics struct {
+	Version    string
+	Commit     string
+	Branch     string
+	GoVersion  string
+	GoOS       string
+	GoArch     string
+	GoMaxProcs int64
+	Hostname   string
+	PID        int64
+	Started    time.Time
+	Uptime     time.Duration
+}
+
+// ParseError is returned when an InfluxQL statement cannot be parsed,
+// and contains the line and character where the error occurred
+type ParseError struct {
+	Message string
+	Line    int
+	Char    int
+}
+
+// FieldTypeConflictError is returned when a value is written to a field
+// which already exists with a different type
+type FieldTypeConflictError struct {
+	Measurement string
+	Field       string
+	Type        string
+	Existing    string
+}
+
+// PartialWriteError is returned when the server rejects some of the points
+// in a write, where Err is the reason the points were rejected
+type PartialWriteError struct {
+	Rejected int
+	Err      error
+}
+
+// StatementError is the error for a statement in a batch of queries,
+// where Statement is the index of the query in the batch
+type StatementError struct {
+	Statement int
+	Err       error
+}
+
+// BatchError is returned when one or more statements in a batch of
+// queries fail
+type BatchError []*StatementError
+
 // Result reflects the influxdb model.Row structure but which defines a number
 // of additional methods
 type Result struct {
-	Result  int
-	Series  int
-	Name    string
-	Tags    map[string]string
-	Columns []string
-	Values  [][]interface{}
-	Partial bool
+	Result   int
+	Series   int
+	Name     string
+	Tags     map[string]string
+	Columns  []string
+	Values   [][]interface{}
+	Partial  bool
+	Err      error
+	Messages []*Message
+}
+
+// Message is an informational message or warning returned by the server
+// for a statement
+type Message struct {
+	Level string
+	Text  string
 }
 
 // Results is a set of results (usually one, but may be more if more than one measure
@@ -93,11 +356,23 @@ type Results []*Result
 // Value is a value returned by influxdb
 type Value interface{}
 
-// Measurement defines a measurement
+// Measurement defines a measurement, which is a regular expression
+// when Regexp is true, or a subquery when Query is set
 type Measurement struct {
 	Name     string
 	Database string
 	Policy   string
+	Regexp   bool
+	Query    Query
+}
+
+// Point is a single row of data for a measurement, with tags, fields
+// and an optional timestamp
+type Point struct {
+	Name   string
+	Tags   map[string]string
+	Fields map[string]Value
+	Time   time.Time
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -121,8 +396,50 @@ type Client interface {
 	DropRetentionPolicy(name string) error
 	RetentionPolicies() (map[string]*RetentionPolicy, error)
 
-	// Excute a query
+	// Convenience methods for continuous queries
+	ContinuousQueries() ([]*ContinuousQuery, error)
+	CreateContinuousQuery(name string, query Query, every, for_ time.Duration) error
+	DropContinuousQuery(name string) error
+
+	// Return the tag keys and field keys for the current database
+	Schema() (Schema, error)
+
+	// Return server statistics and diagnostics
+	Stats() (*Stats, error)
+	Diagnostics() (*Diagnostics, error)
+
+	// Return shards and shard groups, and drop a shard
+	Shards() ([]*Shard, error)
+	ShardGroups() ([]*ShardGroup, error)
+	DropShard(id uint64) error
+
+	// Return running queries and kill a running query
+	Queries() ([]*QueryInfo, error)
+	KillQuery(id uint64) error
+
+	// Return the result of a cardinality query
+	Cardinality(query Query) (uint64, error)
+
+	// Convenience methods for users and privileges
+	Users() ([]*User, error)
+	CreateUser(name, password string, admin bool) error
+	DropUser(name string) error
+	SetPassword(name, password string) error
+	Grants(name string) ([]*Privilege, error)
+	Grant(name, database, privilege string) error
+	Revoke(name, database, privilege string) error
+
+	// Excute a query, and execute a query with values for Param placeholders
 	Do(query Query) (Results, error)
+	DoWithParams(query Query, params map[string]interface{}) (Results, error)
+
+	// Execute several queries in one request, and return the results
+	// for each query
+	DoBatch(queries ...Query) ([]Results, error)
+
+	// Execute a SELECT INTO query over a time range in chunks, and
+	// return the number of points written
+	Backfill(query Query, from, to time.Time, chunk time.Duration, filter ...Predicate) (uint64, error)
 
 	// Return an empty dataset and write data
 	NewDataset(name string, tags, fields []string) (Dataset, error)
@@ -147,6 +464,18 @@ type Dataset interface {
 	AddValuesForTimestamp(ts time.Time, values ...Value) error
 }
 
+// Writer is an abstract background writer, which buffers points
+// and writes them to the database in batches
+type Writer interface {
+	gopi.Driver
+
+	// Add points to the buffer
+	Add(points ...*Point) error
+
+	// Write all buffered points to the database
+	Flush() error
+}
+
 // Query is the abstract InfluxQL statement interface
 type Query interface {
 	// Set parameters
@@ -156,6 +485,16 @@ type Query interface {
 	Measurement(values ...*Measurement) Query
 	OffsetLimit(offset uint, limit uint) Query
 	Filter(values ...Predicate) Query
+	Columns(values ...Predicate) Query
+	GroupBy(values ...Predicate) Query
+	Fill(value Value) Query
+	TZ(value string) Query
+	Descending(value bool) Query
+	SeriesOffsetLimit(offset uint, limit uint) Query
+	Into(value *Measurement) Query
+
+	// Return an error if a parameter is not supported by the query
+	Err() error
 
 	// Return the query as a string
 	String() string
@@ -0,0 +1,135 @@
+/*
+	InfluxDB client
+	(c) Copyright David Thorpe 2017
+	All Rights Reserved
+
+	For Licensing and Usage information, please see LICENSE file
+*/
+
+package influxdb
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	regexpParseError        = regexp.MustCompile(`^(?:error parsing query: )?(.+) at line (\d+), char (\d+)$`)
+	regexpFieldTypeConflict = regexp.MustCompile(`field type conflict: input field "(.*)" on measurement "(.*)" is type (\w+), already exists as type (\w+)`)
+	regexpPartialWrite      = regexp.MustCompile(`^partial write:\s*(.*?)\s*dropped=(\d+)$`)
+	regexpStatusCode        = regexp.MustCompile(`status(?: code)?:? (\d{3})\b`)
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ServerError returns a typed error for an error message returned by the
+// server, or an untyped error if the message is not recognised. The message
+// may be JSON, as returned for writes, or include the HTTP status code
+func ServerError(message string) error {
+	message = strings.TrimSpace(message)
+
+	// Write errors are returned as a JSON object
+	if strings.HasPrefix(message, "{") {
+		var body struct {
+			Err string `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(message), &body); err == nil && body.Err != "" {
+			message = body.Err
+		}
+	}
+
+	if match := regexpPartialWrite.FindStringSubmatch(message); match != nil {
+		rejected, _ := strconv.Atoi(match[2])
+		return &PartialWriteError{Rejected: rejected, Err: ServerError(match[1])}
+	}
+	if match := regexpParseError.FindStringSubmatch(message); match != nil {
+		line, _ := strconv.Atoi(match[2])
+		char, _ := strconv.Atoi(match[3])
+		return &ParseError{Message: match[1], Line: line, Char: char}
+	}
+	if match := regexpFieldTypeConflict.FindStringSubmatch(message); match != nil {
+		return &FieldTypeConflictError{Field: match[1], Measurement: match[2], Type: match[3], Existing: match[4]}
+	}
+
+	switch {
+	case strings.HasPrefix(message, "database not found"):
+		return ErrDatabaseNotFound
+	case strings.HasPrefix(message, "authorization failed"),
+		strings.HasPrefix(message, "error authorizing query"),
+		strings.HasPrefix(message, "unable to parse authentication credentials"),
+		strings.Contains(message, "not authorized"):
+		return ErrAuthorization
+	case strings.HasPrefix(message, "unable to parse"):
+		return &ParseError{Message: message}
+	case message == "timeout",
+		strings.Contains(message, "timeout limit exceeded"):
+		return ErrTimeout
+	case strings.Contains(message, "max-concurrent"),
+		strings.Contains(message, "cache-max-memory-size exceeded"),
+		strings.Contains(message, "too many requests"):
+		return ErrOverloaded
+	case strings.HasPrefix(message, "retention policy not found"),
+		strings.HasPrefix(message, "user not found"),
+		strings.HasPrefix(message, "shard group not found"),
+		strings.HasPrefix(message, "continuous query not found"),
+		strings.HasPrefix(message, "subscription not found"):
+		return ErrNotFound
+	}
+
+	if match := regexpStatusCode.FindStringSubmatch(message); match != nil {
+		status, _ := strconv.Atoi(match[1])
+		return StatusError(status, message)
+	}
+	return errors.New(message)
+}
+
+// StatusError returns a typed error for an HTTP status code, or an untyped
+// error with the message if the status code does not indicate the cause
+func StatusError(status int, message string) error {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuthorization
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrTimeout
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return ErrOverloaded
+	default:
+		return errors.New(message)
+	}
+}
+
+// IsRetryable returns true if a request which failed with err may succeed
+// when it is retried later, because the server timed out, was overloaded
+// or could not be reached for the time being
+func IsRetryable(err error) bool {
+	switch err_ := err.(type) {
+	case *StatementError:
+		return IsRetryable(err_.Err)
+	case *PartialWriteError:
+		return IsRetryable(err_.Err)
+	case *url.Error:
+		return IsRetryable(err_.Err)
+	case *net.OpError:
+		// A server which refuses connections may be restarting, but a host
+		// which cannot be resolved is only retried if the lookup can be
+		if _, ok := err_.Err.(*net.DNSError); ok == false && err_.Op == "dial" {
+			return true
+		}
+		return err_.Timeout() || err_.Temporary()
+	case net.Error:
+		return err_.Timeout() || err_.Temporary()
+	}
+	return err == ErrTimeout || err == ErrOverloaded
+}
@@ -11,9 +11,19 @@ package influxdb
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	regexpResample = regexp.MustCompile(`(?i)\bRESAMPLE\s+(?:EVERY\s+(\w+))?\s*(?:FOR\s+(\w+))?`)
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
@@ -32,6 +42,226 @@ func (r Results) Column(result int, series string, column string) ([]Value, erro
 	return nil, ErrBadParameter
 }
 
+// Err returns the error for the first statement which failed, or nil
+func (r Results) Err() error {
+	for _, result := range r {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
+}
+
+// Statement returns the results for a single statement
+func (r Results) Statement(statement int) Results {
+	results := make(Results, 0, 1)
+	for _, result := range r {
+		if result.Result == statement {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Messages returns the messages for all statements, where the results for
+// each series of a statement share the same messages
+func (r Results) Messages() []*Message {
+	messages := make([]*Message, 0)
+	for i, result := range r {
+		if i > 0 && r[i-1].Result == result.Result {
+			continue
+		}
+		messages = append(messages, result.Messages...)
+	}
+	return messages
+}
+
+// ParseCardinality returns the sum of the counts in the response to a
+// cardinality query, which has a count for each measurement when the
+// cardinality is exact
+func (r Results) ParseCardinality() (uint64, error) {
+	count := uint64(0)
+	for _, result := range r {
+		for _, row := range result.Values {
+			if len(row) != 1 {
+				return 0, ErrUnexpectedResponse
+			} else if n, ok := row[0].(json.Number); ok == false {
+				return 0, ErrUnexpectedResponse
+			} else if n_, err := strconv.ParseUint(n.String(), 10, 64); err != nil {
+				return 0, ErrUnexpectedResponse
+			} else {
+				count += n_
+			}
+		}
+	}
+	return count, nil
+}
+
+// ParseWritten returns the number of points written by a SELECT INTO
+// query, which is in the "written" column of the "result" series
+func (r Results) ParseWritten() (uint64, error) {
+	count := uint64(0)
+	for _, result := range r {
+		if result.Name != "result" {
+			continue
+		} else if i := result.columnindex("written"); i < 0 {
+			return 0, ErrUnexpectedResponse
+		} else {
+			for _, row := range result.Values {
+				if i >= len(row) {
+					return 0, ErrUnexpectedResponse
+				} else if n, ok := row[i].(json.Number); ok == false {
+					return 0, ErrUnexpectedResponse
+				} else if n_, err := n.Int64(); err != nil || n_ < 0 {
+					return 0, ErrUnexpectedResponse
+				} else {
+					count += uint64(n_)
+				}
+			}
+		}
+	}
+	return count, nil
+}
+
+// ParseStats returns server statistics from the response to SHOW STATS,
+// which has a series for each module. The tsm1 series for a shard are
+// combined, and series for other modules are ignored
+func (r Results) ParseStats() (*Stats, error) {
+	stats := &Stats{}
+	tsm1 := make(map[string]*TSM1Stats)
+	for _, result := range r {
+		values := result.statValues()
+		if values == nil {
+			return nil, ErrUnexpectedResponse
+		}
+		switch result.Name {
+		case "runtime":
+			stats.Runtime = &RuntimeStats{
+				Alloc:        statInt(values, "Alloc"),
+				TotalAlloc:   statInt(values, "TotalAlloc"),
+				Sys:          statInt(values, "Sys"),
+				HeapAlloc:    statInt(values, "HeapAlloc"),
+				HeapInUse:    statInt(values, "HeapInUse"),
+				HeapObjects:  statInt(values, "HeapObjects"),
+				Mallocs:      statInt(values, "Mallocs"),
+				Frees:        statInt(values, "Frees"),
+				NumGC:        statInt(values, "NumGC"),
+				NumGoroutine: statInt(values, "NumGoroutine"),
+				PauseTotal:   time.Duration(statInt(values, "PauseTotalNs")),
+			}
+		case "write":
+			stats.Write = &WriteStats{
+				Req:          statInt(values, "req"),
+				PointReq:     statInt(values, "pointReq"),
+				WriteOk:      statInt(values, "writeOk"),
+				WriteError:   statInt(values, "writeError"),
+				WriteDrop:    statInt(values, "writeDrop"),
+				WriteTimeout: statInt(values, "writeTimeout"),
+				SubWriteOk:   statInt(values, "subWriteOk"),
+				SubWriteDrop: statInt(values, "subWriteDrop"),
+			}
+		case "httpd":
+			stats.HTTPD = append(stats.HTTPD, &HTTPDStats{
+				Bind:                 result.Tags["bind"],
+				Req:                  statInt(values, "req"),
+				ReqActive:            statInt(values, "reqActive"),
+				QueryReq:             statInt(values, "queryReq"),
+				WriteReq:             statInt(values, "writeReq"),
+				PingReq:              statInt(values, "pingReq"),
+				ClientError:          statInt(values, "clientError"),
+				ServerError:          statInt(values, "serverError"),
+				AuthFail:             statInt(values, "authFail"),
+				PointsWrittenOK:      statInt(values, "pointsWrittenOK"),
+				PointsWrittenFail:    statInt(values, "pointsWrittenFail"),
+				PointsWrittenDropped: statInt(values, "pointsWrittenDropped"),
+				QueryRespBytes:       statInt(values, "queryRespBytes"),
+				WriteReqBytes:        statInt(values, "writeReqBytes"),
+				ReqDuration:          time.Duration(statInt(values, "reqDurationNs")),
+				QueryReqDuration:     time.Duration(statInt(values, "queryReqDurationNs")),
+				WriteReqDuration:     time.Duration(statInt(values, "writeReqDurationNs")),
+			})
+		case "shard":
+			id, _ := strconv.ParseUint(result.Tags["id"], 10, 64)
+			stats.Shards = append(stats.Shards, &ShardStats{
+				Id:                 id,
+				Database:           result.Tags["database"],
+				Policy:             result.Tags["retentionPolicy"],
+				Engine:             result.Tags["engine"],
+				Path:               result.Tags["path"],
+				DiskBytes:          statInt(values, "diskBytes"),
+				SeriesCreate:       statInt(values, "seriesCreate"),
+				FieldsCreate:       statInt(values, "fieldsCreate"),
+				WriteReq:           statInt(values, "writeReq"),
+				WriteReqOk:         statInt(values, "writeReqOk"),
+				WriteReqErr:        statInt(values, "writeReqErr"),
+				WritePointsOk:      statInt(values, "writePointsOk"),
+				WritePointsErr:     statInt(values, "writePointsErr"),
+				WritePointsDropped: statInt(values, "writePointsDropped"),
+				WriteBytes:         statInt(values, "writeBytes"),
+			})
+		case "tsm1_engine", "tsm1_cache", "tsm1_filestore", "tsm1_wal":
+			key := result.Tags["id"]
+			shard, exists := tsm1[key]
+			if exists == false {
+				id, _ := strconv.ParseUint(key, 10, 64)
+				shard = &TSM1Stats{Id: id, Database: result.Tags["database"], Policy: result.Tags["retentionPolicy"]}
+				tsm1[key] = shard
+				stats.TSM1 = append(stats.TSM1, shard)
+			}
+			switch result.Name {
+			case "tsm1_engine":
+				shard.CacheCompactions = statInt(values, "cacheCompactions")
+			case "tsm1_cache":
+				shard.CacheMemBytes = statInt(values, "memBytes")
+				shard.CacheDiskBytes = statInt(values, "diskBytes")
+				shard.CacheAge = time.Duration(statInt(values, "cacheAgeMs")) * time.Millisecond
+				shard.CacheWriteOk = statInt(values, "writeOk")
+				shard.CacheWriteErr = statInt(values, "writeErr")
+				shard.CacheWriteDropped = statInt(values, "writeDropped")
+			case "tsm1_filestore":
+				shard.FileStoreDiskBytes = statInt(values, "diskBytes")
+				shard.FileStoreFiles = statInt(values, "numFiles")
+			case "tsm1_wal":
+				shard.WALCurrentBytes = statInt(values, "currentSegmentDiskBytes")
+				shard.WALOldBytes = statInt(values, "oldSegmentsDiskBytes")
+				shard.WALWriteOk = statInt(values, "writeOk")
+				shard.WALWriteErr = statInt(values, "writeErr")
+			}
+		}
+	}
+	return stats, nil
+}
+
+// ParseDiagnostics returns server diagnostics from the response to
+// SHOW DIAGNOSTICS, which has a series for each module
+func (r Results) ParseDiagnostics() (*Diagnostics, error) {
+	diagnostics := &Diagnostics{}
+	for _, result := range r {
+		values := result.statValues()
+		if values == nil {
+			return nil, ErrUnexpectedResponse
+		}
+		switch result.Name {
+		case "build":
+			diagnostics.Version = statString(values, "Version")
+			diagnostics.Commit = statString(values, "Commit")
+			diagnostics.Branch = statString(values, "Branch")
+		case "runtime":
+			diagnostics.GoVersion = statString(values, "version")
+			diagnostics.GoOS = statString(values, "GOOS")
+			diagnostics.GoArch = statString(values, "GOARCH")
+			diagnostics.GoMaxProcs = statInt(values, "GOMAXPROCS")
+		case "network":
+			diagnostics.Hostname = statString(values, "hostname")
+		case "system":
+			diagnostics.PID = statInt(values, "PID")
+			diagnostics.Started, _ = timeValue(values["started"])
+			diagnostics.Uptime, _ = time.ParseDuration(statString(values, "uptime"))
+		}
+	}
+	return diagnostics, nil
+}
+
 // ParseRetentionPolicies returns retention policies from a server
 // response
 func (r *Result) ParseRetentionPolicies() (map[string]*RetentionPolicy, error) {
@@ -68,6 +298,231 @@ func (r *Result) ParseRetentionPolicies() (map[string]*RetentionPolicy, error) {
 	return policies, nil
 }
 
+// ParseContinuousQueries returns continuous queries from a server
+// response. The name of the result is the database name
+func (r *Result) ParseContinuousQueries() ([]*ContinuousQuery, error) {
+	queries := make([]*ContinuousQuery, 0, len(r.Values))
+	for _, row := range r.Values {
+		if len(row) != 2 {
+			return nil, ErrUnexpectedResponse
+		}
+		if name, ok := row[0].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if query, ok := row[1].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else {
+			cq := &ContinuousQuery{
+				Name:     name,
+				Database: r.Name,
+				Query:    query,
+			}
+			if match := regexpResample.FindStringSubmatch(query); match != nil {
+				if match[1] != "" {
+					if cq.Every, ok = parseDuration(match[1]); ok == false {
+						return nil, ErrUnexpectedResponse
+					}
+				}
+				if match[2] != "" {
+					if cq.For, ok = parseDuration(match[2]); ok == false {
+						return nil, ErrUnexpectedResponse
+					}
+				}
+			}
+			queries = append(queries, cq)
+		}
+	}
+	return queries, nil
+}
+
+// ParseTagKeys returns tag keys from a server response. The name of the
+// result is the measurement name
+func (r *Result) ParseTagKeys() ([]string, error) {
+	keys := make([]string, 0, len(r.Values))
+	for _, row := range r.Values {
+		if len(row) != 1 {
+			return nil, ErrUnexpectedResponse
+		} else if key, ok := row[0].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// ParseFieldKeys returns field keys and their types from a server
+// response. The name of the result is the measurement name
+func (r *Result) ParseFieldKeys() (map[string]string, error) {
+	keys := make(map[string]string, len(r.Values))
+	for _, row := range r.Values {
+		if len(row) != 2 {
+			return nil, ErrUnexpectedResponse
+		} else if key, ok := row[0].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if type_, ok := row[1].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else {
+			keys[key] = type_
+		}
+	}
+	return keys, nil
+}
+
+// ParseShards returns shards from a server response
+func (r *Result) ParseShards() ([]*Shard, error) {
+	id, database, policy, group, start, end, expiry, owners := r.columnindex("id"), r.columnindex("database"), r.columnindex("retention_policy"), r.columnindex("shard_group"), r.columnindex("start_time"), r.columnindex("end_time"), r.columnindex("expiry_time"), r.columnindex("owners")
+	if id < 0 || database < 0 || policy < 0 || group < 0 || start < 0 || end < 0 || expiry < 0 {
+		return nil, ErrUnexpectedResponse
+	}
+	shards := make([]*Shard, 0, len(r.Values))
+	for _, row := range r.Values {
+		if len(row) != len(r.Columns) {
+			return nil, ErrUnexpectedResponse
+		}
+		shard := &Shard{}
+		ok := true
+		if shard.Id, ok = uintValue(row[id]); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if shard.Database, ok = row[database].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if shard.Policy, ok = row[policy].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if shard.ShardGroup, ok = uintValue(row[group]); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if shard.Start, ok = timeValue(row[start]); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if shard.End, ok = timeValue(row[end]); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if shard.Expiry, ok = timeValue(row[expiry]); ok == false {
+			return nil, ErrUnexpectedResponse
+		}
+		if owners >= 0 {
+			if value, ok := row[owners].(string); ok && value != "" {
+				for _, owner := range strings.Split(value, ",") {
+					if owner_, err := strconv.ParseUint(strings.TrimSpace(owner), 10, 64); err != nil {
+						return nil, ErrUnexpectedResponse
+					} else {
+						shard.Owners = append(shard.Owners, owner_)
+					}
+				}
+			}
+		}
+		shards = append(shards, shard)
+	}
+	return shards, nil
+}
+
+// ParseShardGroups returns shard groups from a server response
+func (r *Result) ParseShardGroups() ([]*ShardGroup, error) {
+	id, database, policy, start, end, expiry := r.columnindex("id"), r.columnindex("database"), r.columnindex("retention_policy"), r.columnindex("start_time"), r.columnindex("end_time"), r.columnindex("expiry_time")
+	if id < 0 || database < 0 || policy < 0 || start < 0 || end < 0 || expiry < 0 {
+		return nil, ErrUnexpectedResponse
+	}
+	groups := make([]*ShardGroup, 0, len(r.Values))
+	for _, row := range r.Values {
+		if len(row) != len(r.Columns) {
+			return nil, ErrUnexpectedResponse
+		}
+		group := &ShardGroup{}
+		ok := true
+		if group.Id, ok = uintValue(row[id]); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if group.Database, ok = row[database].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if group.Policy, ok = row[policy].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if group.Start, ok = timeValue(row[start]); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if group.End, ok = timeValue(row[end]); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if group.Expiry, ok = timeValue(row[expiry]); ok == false {
+			return nil, ErrUnexpectedResponse
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// ParseQueries returns running queries from a server response
+func (r *Result) ParseQueries() ([]*QueryInfo, error) {
+	qid, query, database, duration, status := r.columnindex("qid"), r.columnindex("query"), r.columnindex("database"), r.columnindex("duration"), r.columnindex("status")
+	if qid < 0 || query < 0 {
+		return nil, ErrUnexpectedResponse
+	}
+	queries := make([]*QueryInfo, 0, len(r.Values))
+	for _, row := range r.Values {
+		if len(row) != len(r.Columns) {
+			return nil, ErrUnexpectedResponse
+		}
+		info := &QueryInfo{}
+		if id, ok := row[qid].(json.Number); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if id_, err := strconv.ParseUint(id.String(), 10, 64); err != nil {
+			return nil, ErrUnexpectedResponse
+		} else if info.Query, ok = row[query].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else {
+			info.Id = id_
+		}
+		if database >= 0 {
+			info.Database, _ = row[database].(string)
+		}
+		if status >= 0 {
+			info.Status, _ = row[status].(string)
+		}
+		if duration >= 0 {
+			if value, ok := row[duration].(string); ok == false {
+				return nil, ErrUnexpectedResponse
+			} else if d, err := time.ParseDuration(value); err == nil {
+				info.Duration = d
+			} else if d, ok := parseDuration(value); ok {
+				info.Duration = d
+			} else {
+				return nil, ErrUnexpectedResponse
+			}
+		}
+		queries = append(queries, info)
+	}
+	return queries, nil
+}
+
+// ParseUsers returns users from a server response
+func (r *Result) ParseUsers() ([]*User, error) {
+	users := make([]*User, 0, len(r.Values))
+	for _, row := range r.Values {
+		if len(row) != 2 {
+			return nil, ErrUnexpectedResponse
+		}
+		if name, ok := row[0].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if admin, ok := row[1].(bool); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else {
+			users = append(users, &User{Name: name, Admin: admin})
+		}
+	}
+	return users, nil
+}
+
+// ParsePrivileges returns database privileges for a user from a
+// server response
+func (r *Result) ParsePrivileges() ([]*Privilege, error) {
+	privileges := make([]*Privilege, 0, len(r.Values))
+	for _, row := range r.Values {
+		if len(row) != 2 {
+			return nil, ErrUnexpectedResponse
+		}
+		if database, ok := row[0].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else if privilege, ok := row[1].(string); ok == false {
+			return nil, ErrUnexpectedResponse
+		} else {
+			privileges = append(privileges, &Privilege{Database: database, Privilege: privilege})
+		}
+	}
+	return privileges, nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
@@ -92,6 +547,63 @@ func (r *Result) column(column string) ([]Value, error) {
 	}
 }
 
+// statValues returns the first row of a result as a map of column
+// to value, or nil if there is not exactly one row
+func (r *Result) statValues() map[string]Value {
+	if len(r.Values) != 1 || len(r.Values[0]) != len(r.Columns) {
+		return nil
+	}
+	values := make(map[string]Value, len(r.Columns))
+	for i, column := range r.Columns {
+		values[column] = r.Values[0][i]
+	}
+	return values
+}
+
+// statInt returns an integer statistic, or zero if it is missing
+func statInt(values map[string]Value, key string) int64 {
+	if n, ok := values[key].(json.Number); ok == false {
+		return 0
+	} else if n_, err := n.Int64(); err == nil {
+		return n_
+	} else if f, err := n.Float64(); err == nil {
+		return int64(f)
+	} else {
+		return 0
+	}
+}
+
+// statString returns a string statistic, or an empty string if it is missing
+func statString(values map[string]Value, key string) string {
+	if value, ok := values[key].(string); ok {
+		return value
+	} else {
+		return ""
+	}
+}
+
+// uintValue returns an unsigned integer from a response value
+func uintValue(value interface{}) (uint64, bool) {
+	if n, ok := value.(json.Number); ok == false {
+		return 0, false
+	} else if n_, err := strconv.ParseUint(n.String(), 10, 64); err != nil {
+		return 0, false
+	} else {
+		return n_, true
+	}
+}
+
+// timeValue returns a time from a response value in RFC3339 format
+func timeValue(value interface{}) (time.Time, bool) {
+	if s, ok := value.(string); ok == false {
+		return time.Time{}, false
+	} else if t, err := time.Parse(time.RFC3339Nano, s); err != nil {
+		return time.Time{}, false
+	} else {
+		return t, true
+	}
+}
+
 func toValue(col string, value interface{}) Value {
 	switch value.(type) {
 	case json.Number:
@@ -30,9 +30,79 @@ func (r Results) String() string {
 }
 
 func (r *Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("<influxdb.Result>{ result=%v err=%v }", r.Result, r.Err)
+	}
 	return fmt.Sprintf("<influxdb.Result>{ result=%v series=%v name=%v columns=%v number_of_rows=%v partial=%v }", r.Result, r.Series, r.Name, r.Columns, len(r.Values), r.Partial)
 }
 
+func (m *Message) String() string {
+	return fmt.Sprintf("%v: %v", m.Level, m.Text)
+}
+
+func (this *FieldTypeConflictError) Error() string {
+	return fmt.Sprintf("Field type conflict: %v on %v is %v, already exists as %v", this.Field, this.Measurement, this.Type, this.Existing)
+}
+
+func (this *ParseError) Error() string {
+	return fmt.Sprintf("%v at line %v, char %v", this.Message, this.Line, this.Char)
+}
+
+func (this *PartialWriteError) Error() string {
+	return fmt.Sprintf("Partial write: %v (%v points rejected)", this.Err, this.Rejected)
+}
+
+func (this *StatementError) Error() string {
+	return fmt.Sprintf("Statement %v: %v", this.Statement, this.Err)
+}
+
+func (this BatchError) Error() string {
+	switch len(this) {
+	case 0:
+		return "No errors"
+	case 1:
+		return this[0].Error()
+	default:
+		return fmt.Sprintf("%v (and %v more errors)", this[0], len(this)-1)
+	}
+}
+
 func (this *RetentionPolicy) String() string {
 	return fmt.Sprintf("<influxdb.RetentionPolicy>{ Duration=%v ShardGroupDuration=%v ReplicationFactor=%v Default=%v }", this.Duration, this.ShardGroupDuration, this.ReplicationFactor, this.Default)
 }
+
+func (this *ContinuousQuery) String() string {
+	return fmt.Sprintf("<influxdb.ContinuousQuery>{ Name=%v Database=%v Every=%v For=%v Query=%v }", this.Name, this.Database, this.Every, this.For, this.Query)
+}
+
+func (this *User) String() string {
+	return fmt.Sprintf("<influxdb.User>{ Name=%v Admin=%v }", this.Name, this.Admin)
+}
+
+func (this *Privilege) String() string {
+	return fmt.Sprintf("<influxdb.Privilege>{ Database=%v Privilege=%v }", this.Database, this.Privilege)
+}
+
+func (this *MeasurementSchema) String() string {
+	return fmt.Sprintf("<influxdb.MeasurementSchema>{ Tags=%v Fields=%v }", this.Tags, this.Fields)
+}
+
+func (this *QueryInfo) String() string {
+	return fmt.Sprintf("<influxdb.QueryInfo>{ Id=%v Database=%v Duration=%v Status=%v Query=%v }", this.Id, this.Database, this.Duration, this.Status, this.Query)
+}
+
+func (this *Shard) String() string {
+	return fmt.Sprintf("<influxdb.Shard>{ Id=%v Database=%v Policy=%v ShardGroup=%v Start=%v End=%v Expiry=%v Owners=%v }", this.Id, this.Database, this.Policy, this.ShardGroup, this.Start, this.End, this.Expiry, this.Owners)
+}
+
+func (this *ShardGroup) String() string {
+	return fmt.Sprintf("<influxdb.ShardGroup>{ Id=%v Database=%v Policy=%v Start=%v End=%v Expiry=%v }", this.Id, this.Database, this.Policy, this.Start, this.End, this.Expiry)
+}
+
+func (this *Stats) String() string {
+	return fmt.Sprintf("<influxdb.Stats>{ Runtime=%v Write=%v HTTPD=%v Shards=%v TSM1=%v }", this.Runtime, this.Write, this.HTTPD, this.Shards, this.TSM1)
+}
+
+func (this *Diagnostics) String() string {
+	return fmt.Sprintf("<influxdb.Diagnostics>{ Version=%v Commit=%v Branch=%v GoVersion=%v GoOS=%v GoArch=%v GoMaxProcs=%v Hostname=%v PID=%v Started=%v Uptime=%v }", this.Version, this.Commit, this.Branch, this.GoVersion, this.GoOS, this.GoArch, this.GoMaxProcs, this.Hostname, this.PID, this.Started, this.Uptime)
+}
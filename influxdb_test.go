@@ -1,7 +1,12 @@
 package influxdb_test
 
 import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
 	"os"
+	"syscall"
 	"testing"
 	"time"
 
@@ -20,17 +25,28 @@ func ServerHost() string {
 	return "rpi3.lan"
 }
 
-func Driver(t *testing.T, db string) influxdb.Driver {
+func Driver(t *testing.T, db string) influxdb.Client {
 	return ActualDriver(t, db)
 }
 
-func MockDriver(t *testing.T, db string) influxdb.Driver {
+// NotPredicate returns the negation of a predicate, and fails the test
+// if it cannot be negated
+func NotPredicate(t *testing.T, value influxdb.Predicate) influxdb.Predicate {
+	if p, err := influxdb.Not(value); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return p
+	}
+}
+
+func MockDriver(t *testing.T, db string) influxdb.Client {
 	configuration := mock.Config{Database: db}
 	if log, err := gopi.Open(logger.Config{}, nil); err != nil {
 		t.Error(err)
 	} else if client, err := gopi.Open(configuration, log.(gopi.Logger)); err != nil {
 		t.Error(err)
-	} else if driver, ok := client.(influxdb.Driver); ok == false {
+	} else if driver, ok := client.(influxdb.Client); ok == false {
 		t.Fatal("mock client does not implement all the required methods")
 	} else {
 		return driver
@@ -38,7 +54,7 @@ func MockDriver(t *testing.T, db string) influxdb.Driver {
 	return nil
 }
 
-func ActualDriver(t *testing.T, db string) influxdb.Driver {
+func ActualDriver(t *testing.T, db string) influxdb.Client {
 	configuration := v2.Config{
 		Database: db,
 		Host:     ServerHost(),
@@ -48,8 +64,8 @@ func ActualDriver(t *testing.T, db string) influxdb.Driver {
 		t.Error(err)
 	} else if client, err := gopi.Open(configuration, log.(gopi.Logger)); err != nil {
 		t.Error(err)
-	} else if driver, ok := client.(influxdb.Driver); ok == false {
-		_ = client.(influxdb.Driver)
+	} else if driver, ok := client.(influxdb.Client); ok == false {
+		_ = client.(influxdb.Client)
 		t.Fatal("v2 client does not implement all the required methods")
 	} else {
 		return driver
@@ -296,6 +312,754 @@ func TestQueries_028(t *testing.T) {
 	}
 }
 
+func TestQueries_029(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "test"}).Columns(influxdb.Field("a"), influxdb.Field("b c"))
+	if query.String() != "SELECT a,\"b c\" FROM test" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_030(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "test"}).Columns(influxdb.As(influxdb.Mean(influxdb.Field("temperature")), "mean temperature"), influxdb.Count(influxdb.Field("*")))
+	if query.String() != "SELECT MEAN(temperature) AS \"mean temperature\",COUNT(*) FROM test" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_031(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "test"}).Columns(influxdb.Percentile(influxdb.Field("value"), 95.5), influxdb.Top(influxdb.Field("value"), 3))
+	if query.String() != "SELECT PERCENTILE(value,95.5),TOP(value,3) FROM test" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_032(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "test"}).Columns(influxdb.NonNegativeDerivative(influxdb.Max(influxdb.Field("bytes")), time.Minute), influxdb.Derivative(influxdb.Field("bytes"), 0))
+	if query.String() != "SELECT NON_NEGATIVE_DERIVATIVE(MAX(bytes),1m),DERIVATIVE(bytes) FROM test" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_033(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Mean(influxdb.Field("value"))).GroupBy(influxdb.Time(5*time.Minute, 0), influxdb.Tag("host")).Fill(influxdb.FILL_PREVIOUS)
+	if query.String() != "SELECT MEAN(value) FROM cpu GROUP BY time(5m),host fill(previous)" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_034(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Max(influxdb.Field("value"))).GroupBy(influxdb.Time(time.Hour, 15*time.Minute), influxdb.Tag("*")).Fill(-1.5)
+	if query.String() != "SELECT MAX(value) FROM cpu GROUP BY time(1h,15m),* fill(-1.5)" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_035(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Count(influxdb.Field("value"))).GroupBy(influxdb.Time(24*time.Hour, -time.Hour)).Fill(0).OffsetLimit(0, 10).TZ("Europe/London")
+	if query.String() != "SELECT COUNT(value) FROM cpu GROUP BY time(1d,-1h) fill(0) LIMIT 10 tz('Europe/London')" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_036(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Last(influxdb.Field("value"))).GroupBy(influxdb.Tag("host name")).Fill(influxdb.FILL_NONE)
+	if query.String() != "SELECT LAST(value) FROM cpu GROUP BY \"host name\" fill(none)" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_037(t *testing.T) {
+	from := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour*24 + time.Millisecond*1500 + time.Microsecond)
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TimeRange(from, to, influxdb.PRECISION_MILLI))
+	if query.String() != "SELECT * FROM cpu WHERE time >= '2018-01-01T00:00:00Z' AND time < '2018-01-02T00:00:01.5Z'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_038(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEquals("host", "pi"), influxdb.TimeSince(time.Hour))
+	if query.String() != "SELECT * FROM cpu WHERE host = 'pi' AND time > now() - 1h" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_039(t *testing.T) {
+	from := time.Date(2018, 1, 1, 12, 30, 45, 0, time.UTC)
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TimeAfter(from, influxdb.PRECISION_HOUR))
+	if query.String() != "SELECT * FROM cpu WHERE time >= '2018-01-01T12:00:00Z'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_040(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "env"}).Filter(influxdb.Or(influxdb.FieldGreater("temp", 70), influxdb.FieldLess("humidity", 10.5)), influxdb.TagMatches("host", "pi-"))
+	if query.String() != "SELECT * FROM env WHERE (temp > 70 OR humidity < 10.5) AND host =~ /pi-/" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_041(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "env"}).Filter(influxdb.Or(influxdb.And(influxdb.FieldEquals("up", true), influxdb.FieldNotEquals("state", "it's \"now\"")), influxdb.FieldGreaterEquals("errors", 1)))
+	if query.String() != "SELECT * FROM env WHERE (up = true AND state != 'it\\'s \"now\"') OR errors >= 1" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_042(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "env"}).Filter(NotPredicate(t, influxdb.Or(influxdb.FieldGreater("temp", 70), influxdb.And(influxdb.TagEquals("host", "a", "b"), influxdb.FieldLessEquals("humidity", 10)))))
+	if query.String() != "SELECT * FROM env WHERE temp <= 70 AND ((host != 'a' AND host != 'b') OR humidity > 10)" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_043(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Descending(true).OffsetLimit(5, 10).SeriesOffsetLimit(2, 1)
+	if query.String() != "SELECT * FROM cpu ORDER BY time DESC LIMIT 10 OFFSET 5 SLIMIT 1 SOFFSET 2" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_044(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "^cpu.*", Regexp: true}, &influxdb.Measurement{Name: "/^mem/", Database: "db", Policy: "policy", Regexp: true})
+	if query.String() != "SELECT * FROM /^cpu.*/,db.\"policy\"./^mem/" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_045(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu", Policy: "autogen"}, &influxdb.Measurement{Name: "mem", Database: "db"})
+	if query.String() != "SELECT * FROM autogen.cpu,db..mem" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_046(t *testing.T) {
+	into := &influxdb.Measurement{Name: influxdb.MEASUREMENT_BACKREFERENCE, Database: "db", Policy: "year"}
+	query := influxdb.Select(&influxdb.Measurement{Name: ".*", Regexp: true}).Columns(influxdb.Mean(influxdb.Field("*"))).Into(into).GroupBy(influxdb.Time(time.Hour, 0), influxdb.Tag("*"))
+	if query.String() != "SELECT MEAN(*) INTO db.year.:MEASUREMENT FROM /.*/ GROUP BY time(1h),*" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_047(t *testing.T) {
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Into(&influxdb.Measurement{Name: "cpu copy", Policy: "archive"})
+	if query.String() != "SELECT * INTO archive.\"cpu copy\" FROM cpu" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_048(t *testing.T) {
+	subquery := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Mean(influxdb.Field("value"))).GroupBy(influxdb.Time(time.Minute, 0))
+	query := influxdb.Select(influxdb.Subquery(subquery)).Columns(influxdb.Max(influxdb.Field("mean")))
+	if query.String() != "SELECT MAX(mean) FROM (SELECT MEAN(value) FROM cpu GROUP BY time(1m))" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_049(t *testing.T) {
+	inner := influxdb.Select(&influxdb.Measurement{Name: "cpu"}, &influxdb.Measurement{Name: "mem"}).Filter(influxdb.TagEquals("host", "a"))
+	middle := influxdb.Select(influxdb.Subquery(inner)).Columns(influxdb.As(influxdb.Sum(influxdb.Field("value")), "total")).GroupBy(influxdb.Time(time.Hour, 0))
+	query := influxdb.Select(influxdb.Subquery(middle)).Columns(influxdb.Max(influxdb.Field("total"))).Filter(influxdb.FieldGreater("total", 0))
+	if query.String() != "SELECT MAX(total) FROM (SELECT SUM(value) AS total FROM (SELECT * FROM cpu,mem WHERE host = 'a') GROUP BY time(1h)) WHERE total > 0" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+
+	// Setting the database sets it for copies of the subqueries
+	if query := query.Database("db"); query.String() != "SELECT MAX(total) FROM (SELECT SUM(value) AS total FROM (SELECT * FROM db..cpu,db..mem WHERE host = 'a') GROUP BY time(1h)) WHERE total > 0" {
+		t.Errorf("Unexpected query: %v", query.String())
+	} else if inner.String() != "SELECT * FROM cpu,mem WHERE host = 'a'" {
+		t.Errorf("Unexpected subquery: %v", inner.String())
+	} else if middle.String() != "SELECT SUM(value) AS total FROM (SELECT * FROM cpu,mem WHERE host = 'a') GROUP BY time(1h)" {
+		t.Errorf("Unexpected subquery: %v", middle.String())
+	}
+}
+
+func TestQueries_050(t *testing.T) {
+	select_ := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Mean(influxdb.Field("*"))).Into(&influxdb.Measurement{Name: influxdb.MEASUREMENT_BACKREFERENCE, Policy: "year"}).GroupBy(influxdb.Time(time.Hour, 0), influxdb.Tag("*"))
+	query := influxdb.CreateContinuousQuery("db", "cq_1h", select_, 0, 0)
+	if query.String() != "CREATE CONTINUOUS QUERY cq_1h ON db BEGIN SELECT MEAN(*) INTO year.:MEASUREMENT FROM cpu GROUP BY time(1h),* END" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_051(t *testing.T) {
+	select_ := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Max(influxdb.Field("value"))).Into(&influxdb.Measurement{Name: "cpu_max"}).GroupBy(influxdb.Time(time.Minute*30, 0))
+	query := influxdb.CreateContinuousQuery("db", "cq max", select_, time.Minute*15, time.Hour*2)
+	if query.String() != "CREATE CONTINUOUS QUERY \"cq max\" ON db RESAMPLE EVERY 15m FOR 2h BEGIN SELECT MAX(value) INTO cpu_max FROM cpu GROUP BY time(30m) END" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_052(t *testing.T) {
+	if query := influxdb.ShowContinuousQueries(); query.String() != "SHOW CONTINUOUS QUERIES" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.DropContinuousQuery("db", "cq_1h"); query.String() != "DROP CONTINUOUS QUERY cq_1h ON db" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestContinuousQueries_001(t *testing.T) {
+	result := &influxdb.Result{
+		Name:    "db",
+		Columns: []string{"name", "query"},
+		Values: [][]interface{}{
+			{"cq_1h", "CREATE CONTINUOUS QUERY cq_1h ON db BEGIN SELECT mean(*) INTO db.year.:MEASUREMENT FROM db.autogen.cpu GROUP BY time(1h), * END"},
+			{"cq_max", "CREATE CONTINUOUS QUERY cq_max ON db RESAMPLE EVERY 15m FOR 1h30m BEGIN SELECT max(value) INTO db.autogen.cpu_max FROM db.autogen.cpu GROUP BY time(30m) END"},
+		},
+	}
+	if queries, err := result.ParseContinuousQueries(); err != nil {
+		t.Error(err)
+	} else if len(queries) != 2 {
+		t.Errorf("Expected two queries, got %v", len(queries))
+	} else if queries[0].Name != "cq_1h" || queries[0].Database != "db" || queries[0].Every != 0 || queries[0].For != 0 {
+		t.Errorf("Unexpected query: %v", queries[0])
+	} else if queries[1].Name != "cq_max" || queries[1].Every != time.Minute*15 || queries[1].For != time.Minute*90 {
+		t.Errorf("Unexpected query: %v", queries[1])
+	}
+}
+
+func TestQueries_053(t *testing.T) {
+	if query := influxdb.ShowUsers(); query.String() != "SHOW USERS" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.CreateUser("reader", "it's secret", false); query.String() != "CREATE USER reader WITH PASSWORD 'it\\'s secret'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.CreateUser("admin user", "secret", true); query.String() != "CREATE USER \"admin user\" WITH PASSWORD 'secret' WITH ALL PRIVILEGES" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.SetPassword("reader", "secret"); query.String() != "SET PASSWORD FOR reader = 'secret'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.DropUser("reader"); query.String() != "DROP USER reader" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_054(t *testing.T) {
+	if query := influxdb.Grant(influxdb.PRIVILEGE_READ, "db", "reader"); query.String() != "GRANT READ ON db TO reader" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.Grant(influxdb.PRIVILEGE_ALL, "", "admin"); query.String() != "GRANT ALL PRIVILEGES TO admin" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.Revoke(influxdb.PRIVILEGE_WRITE, "db", "writer"); query.String() != "REVOKE WRITE ON db FROM writer" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowGrants("reader"); query.String() != "SHOW GRANTS FOR reader" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_055(t *testing.T) {
+	query := influxdb.ShowTagKeys().Database("db").Measurement(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEquals("host", "pi")).OffsetLimit(5, 10)
+	if query.String() != "SHOW TAG KEYS ON db FROM cpu WHERE host = 'pi' LIMIT 10 OFFSET 5" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_056(t *testing.T) {
+	if query := influxdb.ShowTagValues("host").Measurement(&influxdb.Measurement{Name: "cpu"}); query.String() != "SHOW TAG VALUES FROM cpu WITH KEY = host" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowTagValues("host", "region name").Filter(influxdb.TagNotEquals("host", "a")).OffsetLimit(0, 5); query.String() != "SHOW TAG VALUES WITH KEY IN (host,\"region name\") WHERE host != 'a' LIMIT 5" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_057(t *testing.T) {
+	query := influxdb.ShowFieldKeys().Database("db").Measurement(&influxdb.Measurement{Name: "cpu"})
+	if query.String() != "SHOW FIELD KEYS ON db FROM cpu" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_058(t *testing.T) {
+	query := influxdb.ShowSeries().Database("db").Measurement(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEquals("host", "pi-3"), influxdb.TagMatches("region", "^eu"))
+	if query.String() != "SHOW SERIES ON db FROM cpu WHERE host = 'pi-3' AND region =~ /^eu/" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_059(t *testing.T) {
+	if query := influxdb.ShowMeasurements().Measurement(&influxdb.Measurement{Name: "^cpu", Regexp: true}).Filter(influxdb.TagEquals("host", "pi")); query.String() != "SHOW MEASUREMENTS WITH MEASUREMENT =~ /^cpu/ WHERE host = 'pi'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowMeasurements().Measurement(&influxdb.Measurement{Name: "cpu"}); query.String() != "SHOW MEASUREMENTS WITH MEASUREMENT = cpu" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_060(t *testing.T) {
+	if query := influxdb.ShowSeriesCardinality(false).Database("db"); query.String() != "SHOW SERIES CARDINALITY ON db" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowSeriesCardinality(true).Measurement(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEquals("host", "pi")); query.String() != "SHOW SERIES EXACT CARDINALITY FROM cpu WHERE host = 'pi'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowMeasurementCardinality(true); query.String() != "SHOW MEASUREMENT EXACT CARDINALITY" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowTagValuesCardinality(false, "host"); query.String() != "SHOW TAG VALUES CARDINALITY WITH KEY = host" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowFieldKeyCardinality(true).Database("db"); query.String() != "SHOW FIELD KEY EXACT CARDINALITY ON db" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestCardinality_001(t *testing.T) {
+	results := influxdb.Results{
+		&influxdb.Result{Name: "cpu", Columns: []string{"count"}, Values: [][]interface{}{{json.Number("10")}}},
+		&influxdb.Result{Name: "mem", Columns: []string{"count"}, Values: [][]interface{}{{json.Number("32")}}},
+	}
+	if count, err := results.ParseCardinality(); err != nil {
+		t.Error(err)
+	} else if count != 42 {
+		t.Errorf("Expected 42, got %v", count)
+	}
+}
+
+func TestQueries_061(t *testing.T) {
+	query := influxdb.Delete(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEquals("host", "pi-3"), influxdb.TimeSince(time.Hour*24))
+	if query.String() != "DELETE FROM cpu WHERE host = 'pi-3' AND time > now() - 1d" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_062(t *testing.T) {
+	if query := influxdb.DropSeries(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEquals("host", "pi-3")); query.String() != "DROP SERIES FROM cpu WHERE host = 'pi-3'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.DropSeries(nil).Filter(influxdb.TagEquals("host", "pi-3")); query.String() != "DROP SERIES WHERE host = 'pi-3'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.DropMeasurement("cpu load"); query.String() != "DROP MEASUREMENT \"cpu load\"" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_063(t *testing.T) {
+	if query := influxdb.ShowQueries(); query.String() != "SHOW QUERIES" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.KillQuery(36); query.String() != "KILL QUERY 36" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_064(t *testing.T) {
+	result := &influxdb.Result{
+		Columns: []string{"qid", "query", "database", "duration", "status"},
+		Values: [][]interface{}{
+			{json.Number("36"), "SELECT mean(value) FROM cpu", "db", "1m30s", "running"},
+			{json.Number("37"), "SHOW QUERIES", "", "56µs", "running"},
+		},
+	}
+	if queries, err := result.ParseQueries(); err != nil {
+		t.Error(err)
+	} else if len(queries) != 2 {
+		t.Errorf("Expected two queries, got %v", len(queries))
+	} else if queries[0].Id != 36 || queries[0].Database != "db" || queries[0].Duration != time.Second*90 || queries[0].Status != "running" {
+		t.Errorf("Unexpected query: %v", queries[0])
+	} else if queries[1].Id != 37 || queries[1].Duration != time.Microsecond*56 {
+		t.Errorf("Unexpected query: %v", queries[1])
+	}
+}
+
+func TestQueries_065(t *testing.T) {
+	if query := influxdb.ShowShards(); query.String() != "SHOW SHARDS" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowShardGroups(); query.String() != "SHOW SHARD GROUPS" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.DropShard(12); query.String() != "DROP SHARD 12" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestShards_001(t *testing.T) {
+	result := &influxdb.Result{
+		Name:    "db",
+		Columns: []string{"id", "database", "retention_policy", "shard_group", "start_time", "end_time", "expiry_time", "owners"},
+		Values: [][]interface{}{
+			{json.Number("3"), "db", "autogen", json.Number("2"), "2018-01-01T00:00:00Z", "2018-01-08T00:00:00Z", "2018-01-15T00:00:00Z", ""},
+			{json.Number("4"), "db", "autogen", json.Number("3"), "2018-01-08T00:00:00Z", "2018-01-15T00:00:00Z", "2018-01-22T00:00:00Z", "1,2"},
+		},
+	}
+	if shards, err := result.ParseShards(); err != nil {
+		t.Error(err)
+	} else if len(shards) != 2 {
+		t.Errorf("Expected two shards, got %v", len(shards))
+	} else if shards[0].Id != 3 || shards[0].Policy != "autogen" || shards[0].ShardGroup != 2 || len(shards[0].Owners) != 0 {
+		t.Errorf("Unexpected shard: %v", shards[0])
+	} else if shards[1].End.Sub(shards[1].Start) != time.Hour*24*7 || len(shards[1].Owners) != 2 || shards[1].Owners[1] != 2 {
+		t.Errorf("Unexpected shard: %v", shards[1])
+	}
+}
+
+func TestShards_002(t *testing.T) {
+	result := &influxdb.Result{
+		Name:    "shard groups",
+		Columns: []string{"id", "database", "retention_policy", "start_time", "end_time", "expiry_time"},
+		Values: [][]interface{}{
+			{json.Number("2"), "db", "autogen", "2018-01-01T00:00:00Z", "2018-01-08T00:00:00Z", "2018-01-15T00:00:00Z"},
+		},
+	}
+	if groups, err := result.ParseShardGroups(); err != nil {
+		t.Error(err)
+	} else if len(groups) != 1 {
+		t.Errorf("Expected one shard group, got %v", len(groups))
+	} else if groups[0].Id != 2 || groups[0].Database != "db" || groups[0].Expiry.Sub(groups[0].End) != time.Hour*24*7 {
+		t.Errorf("Unexpected shard group: %v", groups[0])
+	}
+}
+
+func TestQueries_066(t *testing.T) {
+	if query := influxdb.ShowStats(""); query.String() != "SHOW STATS" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowStats("shard"); query.String() != "SHOW STATS FOR 'shard'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowDiagnostics(); query.String() != "SHOW DIAGNOSTICS" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestStats_001(t *testing.T) {
+	results := influxdb.Results{
+		&influxdb.Result{Name: "runtime", Columns: []string{"Alloc", "NumGoroutine", "PauseTotalNs"}, Values: [][]interface{}{{json.Number("1024"), json.Number("12"), json.Number("5000")}}},
+		&influxdb.Result{Name: "httpd", Tags: map[string]string{"bind": ":8086"}, Columns: []string{"req", "queryReq"}, Values: [][]interface{}{{json.Number("10"), json.Number("4")}}},
+		&influxdb.Result{Name: "shard", Tags: map[string]string{"id": "3", "database": "db", "retentionPolicy": "autogen"}, Columns: []string{"diskBytes", "seriesCreate"}, Values: [][]interface{}{{json.Number("4096"), json.Number("2")}}},
+		&influxdb.Result{Name: "tsm1_cache", Tags: map[string]string{"id": "3", "database": "db"}, Columns: []string{"memBytes", "cacheAgeMs"}, Values: [][]interface{}{{json.Number("512"), json.Number("1500")}}},
+		&influxdb.Result{Name: "tsm1_wal", Tags: map[string]string{"id": "3", "database": "db"}, Columns: []string{"currentSegmentDiskBytes"}, Values: [][]interface{}{{json.Number("256")}}},
+		&influxdb.Result{Name: "queryExecutor", Columns: []string{"queriesActive"}, Values: [][]interface{}{{json.Number("1")}}},
+	}
+	if stats, err := results.ParseStats(); err != nil {
+		t.Error(err)
+	} else if stats.Runtime == nil || stats.Runtime.Alloc != 1024 || stats.Runtime.NumGoroutine != 12 || stats.Runtime.PauseTotal != 5*time.Microsecond {
+		t.Errorf("Unexpected runtime stats: %v", stats.Runtime)
+	} else if stats.Write != nil {
+		t.Errorf("Unexpected write stats: %v", stats.Write)
+	} else if len(stats.HTTPD) != 1 || stats.HTTPD[0].Bind != ":8086" || stats.HTTPD[0].QueryReq != 4 {
+		t.Errorf("Unexpected httpd stats: %v", stats.HTTPD)
+	} else if len(stats.Shards) != 1 || stats.Shards[0].Id != 3 || stats.Shards[0].Policy != "autogen" || stats.Shards[0].DiskBytes != 4096 {
+		t.Errorf("Unexpected shard stats: %v", stats.Shards)
+	} else if len(stats.TSM1) != 1 || stats.TSM1[0].CacheMemBytes != 512 || stats.TSM1[0].CacheAge != 1500*time.Millisecond || stats.TSM1[0].WALCurrentBytes != 256 {
+		t.Errorf("Unexpected tsm1 stats: %v", stats.TSM1)
+	}
+}
+
+func TestDiagnostics_001(t *testing.T) {
+	results := influxdb.Results{
+		&influxdb.Result{Name: "build", Columns: []string{"Branch", "Commit", "Version"}, Values: [][]interface{}{{"1.8", "abc", "1.8.10"}}},
+		&influxdb.Result{Name: "runtime", Columns: []string{"GOARCH", "GOMAXPROCS", "GOOS", "version"}, Values: [][]interface{}{{"arm", json.Number("4"), "linux", "go1.13.8"}}},
+		&influxdb.Result{Name: "system", Columns: []string{"PID", "started", "uptime"}, Values: [][]interface{}{{json.Number("42"), "2018-01-01T00:00:00Z", "1h30m0s"}}},
+	}
+	if diagnostics, err := results.ParseDiagnostics(); err != nil {
+		t.Error(err)
+	} else if diagnostics.Version != "1.8.10" || diagnostics.GoArch != "arm" || diagnostics.GoMaxProcs != 4 {
+		t.Errorf("Unexpected diagnostics: %v", diagnostics)
+	} else if diagnostics.PID != 42 || diagnostics.Started.Year() != 2018 || diagnostics.Uptime != 90*time.Minute {
+		t.Errorf("Unexpected diagnostics: %v", diagnostics)
+	}
+}
+
+func TestQueries_067(t *testing.T) {
+	if query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEqualsParam("host", "host"), influxdb.FieldGreater("value", influxdb.Param("min value"))); query.String() != "SELECT * FROM cpu WHERE host = $host AND value > $\"min value\"" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.ShowSeries().Filter(influxdb.TagEqualsParam("host", "host name"), NotPredicate(t, influxdb.TagEqualsParam("region", "region"))); query.String() != "SHOW SERIES WHERE host = $\"host name\" AND region != $region" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEquals("host", "$host")); query.String() != "SELECT * FROM cpu WHERE host = '$host'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+	if query, err := influxdb.ParseQuery("SELECT * FROM cpu WHERE host = $host AND time > $start"); err != nil {
+		t.Error(err)
+	} else if query.String() != "SELECT * FROM cpu WHERE host = $host AND time > $start" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_068(t *testing.T) {
+	// Setters which are not supported by a statement return a query which
+	// cannot be executed
+	tests := []influxdb.Query{
+		influxdb.ShowDatabases().Fill(0),
+		influxdb.ShowSeries().GroupBy(influxdb.Tag("host")),
+		influxdb.Delete(&influxdb.Measurement{Name: "cpu"}).Descending(true),
+		influxdb.ShowTagKeys().TZ("Europe/London").OffsetLimit(0, 10),
+		influxdb.DropSeries(&influxdb.Measurement{Name: "cpu"}).Into(&influxdb.Measurement{Name: "cpu2"}),
+		influxdb.ShowMeasurements().Columns(influxdb.Field("*")),
+		influxdb.ShowSeriesCardinality(true).SeriesOffsetLimit(0, 10),
+		influxdb.ShowFieldKeys().Filter(influxdb.TagEquals("host", "pi")),
+		influxdb.Select(influxdb.Subquery(influxdb.ShowDatabases().Fill(0))),
+		influxdb.CreateContinuousQuery("db", "cq", influxdb.Select(influxdb.Subquery(influxdb.ShowUsers().TZ("UTC"))), 0, 0),
+	}
+	for i, query := range tests {
+		if err := query.Err(); err != influxdb.ErrNotSupported {
+			t.Errorf("Test %v: Expected ErrNotSupported, got %v", i, err)
+		}
+	}
+	client := MockDriver(t, "")
+	defer client.Close()
+	if _, err := client.Do(tests[0]); err != influxdb.ErrNotSupported {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Mean(influxdb.Field("value"))).GroupBy(influxdb.Time(time.Hour, 0)).Fill(0).TZ("UTC").Descending(true).SeriesOffsetLimit(0, 1)
+	if err := query.Err(); err != nil {
+		t.Error(err)
+	} else if err := influxdb.CreateContinuousQuery("db", "cq", query.Into(&influxdb.Measurement{Name: "cpu_1h"}), 0, 0).Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueries_069(t *testing.T) {
+	// Tag values in the WHERE clause of SHOW statements are string literals
+	where := []influxdb.Predicate{influxdb.TagEquals("host", "pi-3"), influxdb.TagNotEquals("region", "it's")}
+	tests := map[influxdb.Query]string{
+		influxdb.ShowSeries().Filter(where...):                                                       "SHOW SERIES WHERE host = 'pi-3' AND region != 'it\\'s'",
+		influxdb.ShowTagValues("region").Filter(where[0]):                                            "SHOW TAG VALUES WITH KEY = region WHERE host = 'pi-3'",
+		influxdb.ShowSeriesCardinality(false).Filter(where[0]):                                       "SHOW SERIES CARDINALITY WHERE host = 'pi-3'",
+		influxdb.ShowTagValuesCardinality(true, "region").Filter(where...):                           "SHOW TAG VALUES EXACT CARDINALITY WITH KEY = region WHERE host = 'pi-3' AND region != 'it\\'s'",
+		influxdb.ShowMeasurementCardinality(true).Filter(influxdb.TagEquals("host", "pi-3", "pi-4")): "SHOW MEASUREMENT EXACT CARDINALITY WHERE host IN ('pi-3','pi-4')",
+	}
+	for query, expected := range tests {
+		if query.String() != expected {
+			t.Errorf("Expected %v, got %v", expected, query)
+		}
+	}
+}
+
+func TestQueries_070(t *testing.T) {
+	// Fill values which are not supported return a query which cannot
+	// be executed
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Mean(influxdb.Field("value"))).GroupBy(influxdb.Time(time.Hour, 0))
+	for _, value := range []influxdb.Value{"previos", true, []int{1}} {
+		if err := query.Fill(value).Err(); err != influxdb.ErrBadParameter {
+			t.Errorf("%v: Expected ErrBadParameter, got %v", value, err)
+		}
+	}
+	if query := query.Fill(influxdb.FILL_LINEAR); query.Err() != nil {
+		t.Error(query.Err())
+	} else if query.String() != "SELECT MEAN(value) FROM cpu GROUP BY time(1h) fill(linear)" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
+func TestQueries_071(t *testing.T) {
+	// Predicates are added to a copy of the query
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEquals("host", "pi"))
+	if filtered := influxdb.AndFilter(query, influxdb.FieldGreater("value", 1)); filtered.String() != "SELECT * FROM cpu WHERE host = 'pi' AND value > 1" {
+		t.Errorf("Unexpected query: %v", filtered)
+	} else if query.String() != "SELECT * FROM cpu WHERE host = 'pi'" {
+		t.Errorf("Unexpected query: %v", query)
+	}
+	if err := influxdb.AndFilter(influxdb.ShowSeries(), influxdb.TagEquals("host", "pi")).Err(); err != influxdb.ErrNotSupported {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestBatchError_001(t *testing.T) {
+	var err error = influxdb.BatchError{
+		&influxdb.StatementError{Statement: 1, Err: errors.New("database not found: db")},
+		&influxdb.StatementError{Statement: 2, Err: errors.New("not executed")},
+	}
+	if batch, ok := err.(influxdb.BatchError); ok == false {
+		t.Error("Expected BatchError")
+	} else if len(batch) != 2 || batch[1].Statement != 2 {
+		t.Errorf("Unexpected errors: %v", batch)
+	} else if err.Error() != "Statement 1: database not found: db (and 1 more errors)" {
+		t.Errorf("Unexpected error: %v", err)
+	} else if err := batch[:1]; err.Error() != "Statement 1: database not found: db" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestServerError_001(t *testing.T) {
+	if err := influxdb.ServerError("database not found: db"); err != influxdb.ErrDatabaseNotFound {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := influxdb.ServerError("error authorizing query: user not authorized to execute statement 'CREATE DATABASE', requires admin privilege"); err != influxdb.ErrAuthorization {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err, ok := influxdb.ServerError("error parsing query: found FROM, expected identifier at line 1, char 8").(*influxdb.ParseError); ok == false {
+		t.Error("Expected ParseError")
+	} else if err.Message != "found FROM, expected identifier" || err.Line != 1 || err.Char != 8 {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err, ok := influxdb.ServerError(`partial write: field type conflict: input field "value" on measurement "cpu" is type integer, already exists as type float dropped=1`).(*influxdb.PartialWriteError); ok == false {
+		t.Error("Expected PartialWriteError")
+	} else if err, ok := err.Err.(*influxdb.FieldTypeConflictError); ok == false {
+		t.Error("Expected FieldTypeConflictError")
+	} else if err.Measurement != "cpu" || err.Field != "value" || err.Type != "integer" || err.Existing != "float" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := influxdb.ServerError("engine is closed"); err.Error() != "engine is closed" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestServerError_002(t *testing.T) {
+	if err, ok := influxdb.ServerError(`{"error":"partial write: points beyond retention policy dropped=3"}`).(*influxdb.PartialWriteError); ok == false {
+		t.Error("Expected PartialWriteError")
+	} else if err.Rejected != 3 || err.Err.Error() != "points beyond retention policy" {
+		t.Errorf("Unexpected error: %v", err)
+	} else if influxdb.IsRetryable(err) {
+		t.Errorf("Expected %v not to be retryable", err)
+	}
+	if err := influxdb.ServerError(`{"error":"timeout"}`); err != influxdb.ErrTimeout || influxdb.IsRetryable(err) == false {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := influxdb.ServerError("max-concurrent-queries limit exceeded(10, 10)"); err != influxdb.ErrOverloaded || influxdb.IsRetryable(err) == false {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := influxdb.ServerError("received status code 503 from downstream server"); err != influxdb.ErrOverloaded {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := influxdb.ServerError("received status code 401 from server"); err != influxdb.ErrAuthorization || influxdb.IsRetryable(err) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := influxdb.ServerError("retention policy not found: rp"); err != influxdb.ErrNotFound {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := influxdb.StatusError(504, "gateway timeout"); err != influxdb.ErrTimeout {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if influxdb.IsRetryable(&influxdb.StatementError{Statement: 1, Err: influxdb.ErrTimeout}) == false {
+		t.Error("Expected statement error to be retryable")
+	}
+}
+
+func TestServerError_003(t *testing.T) {
+	// Only messages for objects which do not exist return ErrNotFound
+	if err := influxdb.ServerError("user not found"); err != influxdb.ErrNotFound {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := influxdb.ServerError("measurement not found in shard: cpu"); err == influxdb.ErrNotFound || err.Error() != "measurement not found in shard: cpu" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Network errors are retryable when the server cannot be reached for
+	// the time being, but not when the host does not exist
+	tests := map[error]bool{
+		&url.Error{Op: "Post", URL: "http://influxdb/write", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}:                                 true,
+		&url.Error{Op: "Post", URL: "http://influxdb/write", Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "influxdb"}}}: false,
+		&url.Error{Op: "Post", URL: "https://influxdb/write", Err: errors.New("x509: certificate signed by unknown authority")}:                                    false,
+		&net.DNSError{Err: "i/o timeout", Name: "influxdb", IsTimeout: true}:                                                                                       true,
+		&influxdb.PartialWriteError{Rejected: 1, Err: influxdb.ErrOverloaded}:                                                                                      true,
+		&influxdb.PartialWriteError{Rejected: 1, Err: errors.New("points beyond retention policy")}:                                                                false,
+	}
+	for err, retryable := range tests {
+		if influxdb.IsRetryable(err) != retryable {
+			t.Errorf("%v: Expected retryable=%v", err, retryable)
+		}
+	}
+}
+
+func TestResults_001(t *testing.T) {
+	warning := &influxdb.Message{Level: "warning", Text: "deprecated"}
+	results := influxdb.Results{
+		&influxdb.Result{Result: 0, Series: 0, Name: "cpu", Messages: []*influxdb.Message{warning}},
+		&influxdb.Result{Result: 0, Series: 1, Name: "mem", Messages: []*influxdb.Message{warning}},
+		&influxdb.Result{Result: 1, Err: influxdb.ErrDatabaseNotFound},
+	}
+	if err := results.Err(); err != influxdb.ErrDatabaseNotFound {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if statement := results.Statement(0); len(statement) != 2 || statement.Err() != nil {
+		t.Errorf("Unexpected results: %v", statement)
+	}
+	if messages := results.Messages(); len(messages) != 1 || messages[0].String() != "warning: deprecated" {
+		t.Errorf("Unexpected messages: %v", messages)
+	}
+}
+
+func TestParse_001(t *testing.T) {
+	// Queries should be the same after parsing
+	from := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	queries := []influxdb.Query{
+		influxdb.ShowDatabases(),
+		influxdb.ShowSeries().Database("db").Measurement(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TagEquals("host", "a")).OffsetLimit(10, 100),
+		influxdb.ShowMeasurements().Measurement(&influxdb.Measurement{Name: "^cpu", Regexp: true}),
+		influxdb.ShowTagValues("host", "region").Database("db").Measurement(&influxdb.Measurement{Name: "cpu"}),
+		influxdb.ShowSeriesCardinality(true).Database("db").Filter(influxdb.TagEquals("host", "a")),
+		influxdb.ShowTagValuesCardinality(false, "host"),
+		influxdb.CreateRetentionPolicy("db", "week", &influxdb.RetentionPolicy{Duration: time.Hour * 24 * 7}).Default(true),
+		influxdb.CreateContinuousQuery("db", "cq", influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Mean(influxdb.Field("value"))).GroupBy(influxdb.Time(time.Hour, 0), influxdb.Tag("*")).Into(&influxdb.Measurement{Policy: "year", Name: influxdb.MEASUREMENT_BACKREFERENCE}), time.Minute*30, time.Hour*2),
+		influxdb.CreateUser("user", "it's", true),
+		influxdb.Revoke(influxdb.PRIVILEGE_ALL, "db", "user"),
+		influxdb.Delete(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.TimeBefore(from, influxdb.PRECISION_SECOND)),
+		influxdb.ShowStats("shard"),
+		influxdb.KillQuery(12),
+		influxdb.Select(&influxdb.Measurement{Database: "db", Name: "my measurement"}, influxdb.Subquery(influxdb.Select(&influxdb.Measurement{Name: "mem"}))).
+			Columns(influxdb.As(influxdb.NonNegativeDerivative(influxdb.Max(influxdb.Field("value")), time.Second), "rate"), influxdb.Percentile(influxdb.Field("value"), 99.5)).
+			Filter(influxdb.Or(influxdb.TagMatches("host", "^server\\d+$"), NotPredicate(t, influxdb.TagMatches("host", "a/b"))), influxdb.FieldGreater("temp", -1.5), influxdb.TimeSince(time.Hour)).
+			GroupBy(influxdb.Time(time.Minute*10, -time.Minute), influxdb.Tag("host")).Fill(0).
+			Descending(true).OffsetLimit(5, 10).SeriesOffsetLimit(1, 2).TZ("Europe/London"),
+	}
+	for _, query := range queries {
+		if parsed, err := influxdb.ParseQuery(query.String()); err != nil {
+			t.Errorf("%v: %v", query, err)
+		} else if parsed.String() != query.String() {
+			t.Errorf("Expected %v, got %v", query, parsed)
+		}
+	}
+}
+
+func TestParse_002(t *testing.T) {
+	// Queries are returned in canonical form
+	queries := map[string]string{
+		"select mean(value) from cpu where host='a' and (region = 'us' or region <> 'eu') group by time(1h30m) fill(none);": "SELECT MEAN(value) FROM cpu WHERE host = 'a' AND (region = 'us' OR region != 'eu') GROUP BY time(90m) fill(none)",
+		"SELECT * FROM \"db\"..\"cpu\" WHERE time > now() - 60m AND time < '2018-01-01'":                                    "SELECT * FROM db..cpu WHERE time > now() - 1h AND time < '2018-01-01'",
+		"show tag values on db with key = \"select\" -- comment":                                                            "SHOW TAG VALUES ON db WITH KEY = \"select\"",
+		"drop series from cpu where ((host = 'a'))":                                                                         "DROP SERIES FROM cpu WHERE host = 'a'",
+		"select * from cpu where host = \"a\" and region != 'b' and value > \"limit\" and name < 'x'":                       "SELECT * FROM cpu WHERE host = \"a\" AND region != 'b' AND value > \"limit\" AND name < 'x'",
+		"select * from cpu where host in ('a', \"b\", $c)":                                                                  "SELECT * FROM cpu WHERE host IN ('a',\"b\",$c)",
+	}
+	for value, expected := range queries {
+		if query, err := influxdb.ParseQuery(value); err != nil {
+			t.Errorf("%v: %v", value, err)
+		} else if query.String() != expected {
+			t.Errorf("Expected %v, got %v", expected, query)
+		}
+	}
+
+	// Rewrite a query with a database and time bound
+	if query, err := influxdb.ParseQuery("SELECT value FROM cpu"); err != nil {
+		t.Error(err)
+	} else if where, err := influxdb.ParsePredicate("host = 'a' OR host = 'b'"); err != nil {
+		t.Error(err)
+	} else if query := query.Database("db").Filter(where, influxdb.TimeSince(time.Hour)); query.String() != "SELECT value FROM db..cpu WHERE (host = 'a' OR host = 'b') AND time > now() - 1h" {
+		t.Errorf("Unexpected query: %v", query)
+	}
+}
+
+func TestParse_003(t *testing.T) {
+	errors := map[string]string{
+		"":                                      "found EOF, expected SELECT, SHOW, CREATE, ALTER, DROP, DELETE, GRANT, REVOKE, KILL or SET at line 1, char 1",
+		"SELECT value FROM":                     "found EOF, expected measurement at line 1, char 18",
+		"SELECT value\nFROM cpu WHERE host ? 1": "Unexpected character \"?\" at line 2, char 21",
+		"SELECT value FROM cpu WHERE host = 'a": "Unterminated '\\'' at line 1, char 36",
+		"SELECT value FROM cpu LIMIT x":         "found x, expected number at line 1, char 29",
+		"SELECT from FROM cpu":                  "found from, expected field or function at line 1, char 8",
+	}
+	for value, expected := range errors {
+		if _, err := influxdb.ParseQuery(value); err == nil {
+			t.Errorf("%v: Expected error", value)
+		} else if _, ok := err.(*influxdb.ParseError); ok == false {
+			t.Errorf("%v: Expected ParseError, got %v", value, err)
+		} else if err.Error() != expected {
+			t.Errorf("%v: Expected %v, got %v", value, expected, err)
+		}
+	}
+}
+
 func TestCreateDatabase_001(t *testing.T) {
 	db := "TestCreateDatabase_001"
 	if driver := Driver(t, ""); driver == nil {
@@ -483,7 +1247,7 @@ func TestCreateRetentionPolicy_003(t *testing.T) {
 		} else if policy, exists := policies["policy"]; exists == false {
 			t.Error("Missing policy after being created")
 		} else if policy.Duration != time.Hour*1 {
-			t.Error("Invalid policy time, unexpected value %v", policy.Duration)
+			t.Errorf("Invalid policy time, unexpected value %v", policy.Duration)
 		}
 	}
 }
@@ -561,23 +1325,103 @@ func TestSelect_002(t *testing.T) {
 }
 
 func TestWhere_001(t *testing.T) {
-	if where := influxdb.TagEquals("name", "value"); where.String() != "name = \"value\"" {
+	if where := influxdb.TagEquals("name", "value"); where.String() != "name = 'value'" {
 		t.Error("Expected string, got", where.String())
 	}
-	if where := influxdb.TagNotEquals("name", "value"); where.String() != "name != \"value\"" {
+	if where := influxdb.TagNotEquals("name", "value"); where.String() != "name != 'value'" {
 		t.Error("Expected string, got", where.String())
 	}
-	if where := influxdb.TagNotEquals("name with space", "value"); where.String() != "\"name with space\" != \"value\"" {
+	if where := influxdb.TagNotEquals("name with space", "value"); where.String() != "\"name with space\" != 'value'" {
 		t.Error("Expected string, got", where.String())
 	}
-	if where := influxdb.TagNotEquals("name", "\"value\""); where.String() != "name != \"\\\"value\\\"\"" {
+	if where := influxdb.TagNotEquals("name", "it's \"value\""); where.String() != "name != 'it\\'s \"value\"'" {
 		t.Error("Expected string, got", where.String())
 	}
-	if where := influxdb.TagEquals("name", "a", "b"); where.String() != "name IN (\"a\",\"b\")" {
+	if where := influxdb.TagEquals("name", "a", "b"); where.String() != "name IN ('a','b')" {
 		t.Error("Expected string, got", where.String())
 	}
 }
 
+func TestWhere_003(t *testing.T) {
+	// String values are single-quoted literals
+	tests := map[influxdb.Predicate]string{
+		influxdb.TagEquals("host", "pi's"):            "host = 'pi\\'s'",
+		influxdb.TagMatches("host", "^pi"):            "host =~ /^pi/",
+		influxdb.FieldEquals("state", "on"):           "state = 'on'",
+		influxdb.FieldEquals("state", []byte("a\\b")): "state = 'a\\\\b'",
+		influxdb.FieldEquals("value", 1.5):            "value = 1.5",
+		influxdb.FieldEquals("ok", false):             "ok = false",
+	}
+	for where, expected := range tests {
+		if where.String() != expected {
+			t.Errorf("Expected %v, got %v", expected, where.String())
+		}
+	}
+}
+
+func TestNot_001(t *testing.T) {
+	// Comparisons are inverted, and AND and OR use De Morgan's laws
+	tests := map[influxdb.Predicate]string{
+		influxdb.TagEquals("host", "a"):                                         "host != 'a'",
+		influxdb.TagNotEquals("host", "a"):                                      "host = 'a'",
+		influxdb.TagEquals("host", "a", "b"):                                    "host != 'a' AND host != 'b'",
+		influxdb.TagMatches("host", "^a"):                                       "host !~ /^a/",
+		influxdb.FieldEquals("v", 1):                                            "v != 1",
+		influxdb.FieldNotEquals("v", "x"):                                       "v = 'x'",
+		influxdb.FieldGreater("v", 1):                                           "v <= 1",
+		influxdb.FieldGreaterEquals("v", 1):                                     "v < 1",
+		influxdb.FieldLess("v", 1):                                              "v >= 1",
+		influxdb.FieldLessEquals("v", 1):                                        "v > 1",
+		influxdb.TimeSince(time.Hour):                                           "time <= now() - 1h",
+		influxdb.And(influxdb.FieldLess("v", 1), influxdb.FieldGreater("v", 9)): "v >= 1 OR v <= 9",
+		influxdb.Or(influxdb.TagEquals("host", "a"), influxdb.And(influxdb.FieldEquals("v", 1), influxdb.TagMatches("host", "^b"))): "host != 'a' AND (v != 1 OR host !~ /^b/)",
+	}
+	for where, expected := range tests {
+		if not, err := influxdb.Not(where); err != nil {
+			t.Errorf("%v: %v", where, err)
+		} else if not.String() != expected {
+			t.Errorf("%v: Expected %v, got %v", where, expected, not.String())
+		}
+	}
+
+	// Predicates which cannot be inverted return an error
+	for _, where := range []influxdb.Predicate{
+		influxdb.Field("v"),
+		influxdb.Param("v"),
+		influxdb.Mean(influxdb.Field("v")),
+		influxdb.And(influxdb.FieldEquals("v", 1), influxdb.Tag("host")),
+	} {
+		if _, err := influxdb.Not(where); err != influxdb.ErrNotSupported {
+			t.Errorf("%v: Expected ErrNotSupported, got %v", where, err)
+		}
+	}
+}
+
+func TestWhere_004(t *testing.T) {
+	// Compound predicates with a single value are parenthesised by what
+	// they contain
+	a, b, c := influxdb.TagEquals("a", "1"), influxdb.TagEquals("b", "2"), influxdb.TagEquals("c", "3")
+	tests := map[influxdb.Predicate]string{
+		influxdb.And(influxdb.And(influxdb.Or(a, b)), c):                  "(a = '1' OR b = '2') AND c = '3'",
+		influxdb.And(influxdb.Or(influxdb.And(influxdb.Or(a, b))), c):     "(a = '1' OR b = '2') AND c = '3'",
+		influxdb.Or(influxdb.And(influxdb.Or(influxdb.And(a, b))), c):     "(a = '1' AND b = '2') OR c = '3'",
+		influxdb.And(influxdb.Or(a), influxdb.And(c)):                     "a = '1' AND c = '3'",
+		influxdb.And(influxdb.Or(a, b)):                                   "a = '1' OR b = '2'",
+		influxdb.And(influxdb.Or(a, b), influxdb.And()):                   "a = '1' OR b = '2'",
+		NotPredicate(t, influxdb.Or(influxdb.Or(a, b), c)):                "(a != '1' AND b != '2') AND c != '3'",
+		NotPredicate(t, influxdb.And(influxdb.And(influxdb.Or(a, b)), c)): "(a != '1' AND b != '2') OR c != '3'",
+		influxdb.And(NotPredicate(t, influxdb.And(a, b)), c):              "(a != '1' OR b != '2') AND c = '3'",
+	}
+	for where, expected := range tests {
+		if where.String() != expected {
+			t.Errorf("Expected %v, got %v", expected, where.String())
+		}
+	}
+	if query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Filter(influxdb.And(influxdb.Or(a, b)), c); query.String() != "SELECT * FROM cpu WHERE (a = '1' OR b = '2') AND c = '3'" {
+		t.Errorf("Unexpected query: %v", query.String())
+	}
+}
+
 func TestWhere_002(t *testing.T) {
 	db := "_internal"
 	if driver := Driver(t, db); driver == nil {
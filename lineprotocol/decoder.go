@@ -0,0 +1,479 @@
+/*
+	InfluxDB client
+	(c) Copyright David Thorpe 2017
+	All Rights Reserved
+
+	For Licensing and Usage information, please see LICENSE file
+*/
+
+package lineprotocol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	influxdb "github.com/djthorpe/influxdb"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Decoder reads points in line protocol from an io.Reader. Blank lines and
+// comments are ignored, as are the statements in the DDL section of files
+// created by "influx_inspect export"
+type Decoder struct {
+	r         *bufio.Reader
+	precision string
+	unit      int64
+	line      uint
+	column    uint
+	ddl       bool
+	buf       []byte
+}
+
+// SyntaxError is returned when the input is not valid line protocol, and
+// contains the line and column where the error occurred
+type SyntaxError struct {
+	Line    uint
+	Column  uint
+	Message string
+}
+
+// SyntaxErrors is returned by DecodeDatasets when some lines could not
+// be decoded. The datasets for the other lines are returned with it
+type SyntaxErrors []*SyntaxError
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	comment_ddl = "# DDL"
+	comment_dml = "# DML"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTRUCTOR
+
+// NewDecoder returns a decoder which reads from r, with timestamps
+// in nanoseconds
+func NewDecoder(r io.Reader) *Decoder {
+	this := new(Decoder)
+	this.r = bufio.NewReader(r)
+	this.line = 1
+	this.buf = make([]byte, 0, 256)
+	this.SetPrecision(influxdb.PRECISION_NANO)
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Precision returns the precision of timestamps
+func (this *Decoder) Precision() string {
+	return this.precision
+}
+
+// SetPrecision sets the precision of timestamps, which should be one
+// of the PRECISION_ constants
+func (this *Decoder) SetPrecision(value string) error {
+	if unit := precisionUnit(value); unit == 0 {
+		return influxdb.ErrBadParameter
+	} else {
+		this.precision = value
+		this.unit = unit
+		return nil
+	}
+}
+
+// Decode returns the next point, or io.EOF when there are no more points.
+// Points without a timestamp have a zero time value. After a SyntaxError
+// the rest of the line is skipped, so that decoding continues with the
+// next line
+func (this *Decoder) Decode() (*influxdb.Point, error) {
+	for {
+		b, err := this.peek()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case b == '\n' || b == '\r' || b == ' ' || b == '\t':
+			this.next()
+		case b == '#':
+			if line, err := this.readLine(); err != nil {
+				return nil, err
+			} else if strings.HasPrefix(line, comment_ddl) {
+				this.ddl = true
+			} else if strings.HasPrefix(line, comment_dml) {
+				this.ddl = false
+			}
+		case this.ddl:
+			if _, err := this.readLine(); err != nil {
+				return nil, err
+			}
+		default:
+			point, err := this.decodePoint()
+			if _, ok := err.(*SyntaxError); ok {
+				this.readLine()
+			}
+			return point, err
+		}
+	}
+}
+
+// DecodeDatasets reads up to max points (or all points if max is zero)
+// and groups them into datasets by measurement and tag set. The fields for
+// each dataset are the union of the fields of its points. Lines which cannot
+// be decoded are skipped and returned as SyntaxErrors with the datasets for
+// the other lines. It returns io.EOF when there are no more points
+func (this *Decoder) DecodeDatasets(client influxdb.Client, max uint) ([]influxdb.Dataset, error) {
+	keys := make([]string, 0)
+	groups := make(map[string][]*influxdb.Point)
+	errs := make(SyntaxErrors, 0)
+	for count := uint(0); max == 0 || count < max; count++ {
+		point, err := this.Decode()
+		if err == io.EOF {
+			break
+		} else if err_, ok := err.(*SyntaxError); ok {
+			errs = append(errs, err_)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		key := groupKey(point)
+		if _, exists := groups[key]; exists == false {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], point)
+	}
+	if len(keys) == 0 && len(errs) == 0 {
+		return nil, io.EOF
+	}
+
+	datasets := make([]influxdb.Dataset, 0, len(keys))
+	for _, key := range keys {
+		if dataset, err := newDataset(client, groups[key]); err != nil {
+			return nil, err
+		} else {
+			datasets = append(datasets, dataset)
+		}
+	}
+	if len(errs) > 0 {
+		return datasets, errs
+	}
+	return datasets, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *SyntaxError) Error() string {
+	return fmt.Sprintf("line %v, column %v: %v", this.Line, this.Column, this.Message)
+}
+
+func (this SyntaxErrors) Error() string {
+	messages := make([]string, len(this))
+	for i, err := range this {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PARSE POINTS
+
+func (this *Decoder) decodePoint() (*influxdb.Point, error) {
+	point := &influxdb.Point{}
+
+	// Measurement
+	if name, err := this.readToken(escapeMeasurement, "measurement"); err != nil {
+		return nil, err
+	} else {
+		point.Name = name
+	}
+
+	// Tags
+	for {
+		if b, err := this.peek(); err != nil || b != ',' {
+			break
+		}
+		this.next()
+		if key, err := this.readToken(escapeKey, "tag key"); err != nil {
+			return nil, err
+		} else if err := this.expect('='); err != nil {
+			return nil, err
+		} else if value, err := this.readToken(escapeKey, "tag value"); err != nil {
+			return nil, err
+		} else {
+			if point.Tags == nil {
+				point.Tags = make(map[string]string)
+			}
+			point.Tags[key] = value
+		}
+	}
+
+	// Fields
+	if err := this.expect(' '); err != nil {
+		return nil, err
+	}
+	this.skipSpaces()
+	point.Fields = make(map[string]influxdb.Value)
+	for {
+		if key, err := this.readToken(escapeKey, "field key"); err != nil {
+			return nil, err
+		} else if err := this.expect('='); err != nil {
+			return nil, err
+		} else if value, err := this.readFieldValue(); err != nil {
+			return nil, err
+		} else {
+			point.Fields[key] = value
+		}
+		if b, err := this.peek(); err != nil || b != ',' {
+			break
+		}
+		this.next()
+	}
+
+	// Timestamp
+	this.skipSpaces()
+	if b, err := this.peek(); err == nil && b != '\n' {
+		line, column := this.line, this.column
+		if token := this.readUntil(" \t\r\n"); token == "" {
+			return nil, this.errorf("Expected timestamp")
+		} else if ts, err := strconv.ParseInt(token, 10, 64); err != nil {
+			return nil, &SyntaxError{line, column + 1, "Invalid timestamp: " + token}
+		} else {
+			point.Time = time.Unix(0, ts*this.unit)
+		}
+	}
+
+	// End of line
+	this.skipSpaces()
+	if b, err := this.peek(); err == nil && b != '\n' {
+		return nil, this.errorf("Unexpected character %q", b)
+	}
+
+	return point, nil
+}
+
+// readToken reads an escaped identifier until an unescaped separator
+// and returns an error if it is empty
+func (this *Decoder) readToken(table *escapeTable, what string) (string, error) {
+	this.buf = this.buf[:0]
+	for {
+		b, err := this.peek()
+		if err != nil || b == '\n' || b == '\r' {
+			break
+		} else if b == '\\' {
+			this.next()
+			if c, err := this.peek(); err == nil && table[c] == escapeBackslash {
+				this.next()
+				this.buf = append(this.buf, c)
+			} else {
+				this.buf = append(this.buf, b)
+			}
+			continue
+		} else if b == ',' || b == ' ' || (b == '=' && table == escapeKey) {
+			break
+		}
+		this.next()
+		this.buf = append(this.buf, b)
+	}
+	if len(this.buf) == 0 {
+		return "", this.errorf("Missing %v", what)
+	}
+	return string(this.buf), nil
+}
+
+// readFieldValue reads a quoted string, number or boolean
+func (this *Decoder) readFieldValue() (influxdb.Value, error) {
+	if b, err := this.peek(); err == nil && b == '"' {
+		return this.readString()
+	}
+	line, column := this.line, this.column+1
+	token := this.readUntil(", \t\r\n")
+	if token == "" {
+		return nil, &SyntaxError{line, column, "Missing field value"}
+	}
+	switch token {
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+	if strings.IndexByte("+-.0123456789", token[0]) < 0 {
+		return nil, &SyntaxError{line, column, "Invalid field value: " + token}
+	}
+	switch token[len(token)-1] {
+	case 'i':
+		if v, err := strconv.ParseInt(token[:len(token)-1], 10, 64); err == nil {
+			return v, nil
+		}
+	case 'u':
+		if v, err := strconv.ParseUint(token[:len(token)-1], 10, 64); err == nil {
+			return v, nil
+		}
+	default:
+		if v, err := strconv.ParseFloat(token, 64); err == nil {
+			return v, nil
+		}
+	}
+	return nil, &SyntaxError{line, column, "Invalid field value: " + token}
+}
+
+// readString reads a double-quoted string, which may contain newlines
+func (this *Decoder) readString() (string, error) {
+	line, column := this.line, this.column+1
+	this.next()
+	this.buf = this.buf[:0]
+	for {
+		b, err := this.next()
+		if err != nil {
+			return "", &SyntaxError{line, column, "Unterminated string"}
+		} else if b == '"' {
+			return string(this.buf), nil
+		} else if b == '\\' {
+			if c, err := this.peek(); err == nil && escapeString[c] == escapeBackslash {
+				this.next()
+				b = c
+			}
+		}
+		this.buf = append(this.buf, b)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// READ BYTES
+
+// peek returns the next byte without consuming it
+func (this *Decoder) peek() (byte, error) {
+	if b, err := this.r.Peek(1); err != nil {
+		return 0, err
+	} else {
+		return b[0], nil
+	}
+}
+
+// next consumes a byte and updates the line and column
+func (this *Decoder) next() (byte, error) {
+	b, err := this.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b == '\n' {
+		this.line++
+		this.column = 0
+	} else {
+		this.column++
+	}
+	return b, nil
+}
+
+// expect consumes a byte and returns an error if it is not the expected one
+func (this *Decoder) expect(c byte) error {
+	if b, err := this.peek(); err != nil || b != c {
+		if err != nil || b == '\n' || b == '\r' {
+			return this.errorf("Expected %q before end of line", c)
+		} else {
+			return this.errorf("Expected %q but found %q", c, b)
+		}
+	}
+	this.next()
+	return nil
+}
+
+// readUntil returns bytes up to any of the separators
+func (this *Decoder) readUntil(separators string) string {
+	this.buf = this.buf[:0]
+	for {
+		if b, err := this.peek(); err != nil || strings.IndexByte(separators, b) >= 0 {
+			return string(this.buf)
+		} else {
+			this.next()
+			this.buf = append(this.buf, b)
+		}
+	}
+}
+
+// readLine returns the remainder of the line and consumes the newline
+func (this *Decoder) readLine() (string, error) {
+	line := strings.TrimSpace(this.readUntil("\n"))
+	if _, err := this.next(); err != nil && err != io.EOF {
+		return "", err
+	}
+	return line, nil
+}
+
+func (this *Decoder) skipSpaces() {
+	for {
+		if b, err := this.peek(); err != nil || (b != ' ' && b != '\t' && b != '\r') {
+			return
+		}
+		this.next()
+	}
+}
+
+// errorf returns a syntax error at the position of the next byte
+func (this *Decoder) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{this.line, this.column + 1, fmt.Sprintf(format, args...)}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// DATASETS
+
+// groupKey returns the measurement name and tag set of a point
+func groupKey(point *influxdb.Point) string {
+	key := make([]string, 0, len(point.Tags)+1)
+	for k, v := range point.Tags {
+		key = append(key, k+"\x00"+v)
+	}
+	sort.Strings(key)
+	return point.Name + "\x01" + strings.Join(key, "\x01")
+}
+
+// newDataset returns a dataset for points with the same measurement
+// and tag set
+func newDataset(client influxdb.Client, points []*influxdb.Point) (influxdb.Dataset, error) {
+	tags := make([]string, 0, len(points[0].Tags))
+	for k := range points[0].Tags {
+		tags = append(tags, k)
+	}
+	sort.Strings(tags)
+	union := make(map[string]bool)
+	for _, point := range points {
+		for k := range point.Fields {
+			union[k] = true
+		}
+	}
+	fields := make([]string, 0, len(union))
+	for k := range union {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	dataset, err := client.NewDataset(points[0].Name, tags, fields)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range points[0].Tags {
+		dataset.SetTag(k, v)
+	}
+	values := make([]influxdb.Value, len(fields))
+	for _, point := range points {
+		for i, field := range fields {
+			values[i] = point.Fields[field]
+		}
+		if point.Time.IsZero() {
+			err = dataset.AddValues(values...)
+		} else {
+			err = dataset.AddValuesForTimestamp(point.Time, values...)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dataset, nil
+}
@@ -0,0 +1,147 @@
+package lineprotocol_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/lineprotocol"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func TestDecoder_000(t *testing.T) {
+	decoder := lineprotocol.NewDecoder(strings.NewReader(""))
+	if point, err := decoder.Decode(); err != io.EOF {
+		t.Error("Expected io.EOF, got", point, err)
+	}
+}
+
+func TestDecoder_001(t *testing.T) {
+	tests := map[string]*influxdb.Point{
+		"cpu value=1.5": &influxdb.Point{Name: "cpu", Fields: map[string]influxdb.Value{"value": 1.5}},
+		"cpu value=1i,ok=t,name=\"a b\" 100": &influxdb.Point{Name: "cpu", Fields: map[string]influxdb.Value{
+			"value": int64(1), "ok": true, "name": "a b",
+		}, Time: time.Unix(0, 100)},
+		"cpu,host=rpi3,region=uk value=2u": &influxdb.Point{Name: "cpu", Tags: map[string]string{
+			"host": "rpi3", "region": "uk",
+		}, Fields: map[string]influxdb.Value{"value": uint64(2)}},
+		"cpu\\ load\\,x,host\\ name=a\\=b\\,c a\\ b\\=c=-1e3": &influxdb.Point{Name: "cpu load,x", Tags: map[string]string{
+			"host name": "a=b,c",
+		}, Fields: map[string]influxdb.Value{"a b=c": -1000.0}},
+		"cpu v=\"say \\\"hi\\\" \\\\o/\"": &influxdb.Point{Name: "cpu", Fields: map[string]influxdb.Value{"v": "say \"hi\" \\o/"}},
+		"cpu v=\"multi\nline\" -5\r":      &influxdb.Point{Name: "cpu", Fields: map[string]influxdb.Value{"v": "multi\nline"}, Time: time.Unix(0, -5)},
+		"c\\\\pu,t=a\\b v=FALSE":          &influxdb.Point{Name: "c\\\\pu", Tags: map[string]string{"t": "a\\b"}, Fields: map[string]influxdb.Value{"v": false}},
+	}
+	for line, expected := range tests {
+		decoder := lineprotocol.NewDecoder(strings.NewReader(line))
+		if point, err := decoder.Decode(); err != nil {
+			t.Errorf("%q: %v", line, err)
+		} else if reflect.DeepEqual(point, expected) == false || point.Time.Equal(expected.Time) == false {
+			t.Errorf("%q: expected %+v, got %+v", line, expected, point)
+		} else if _, err := decoder.Decode(); err != io.EOF {
+			t.Errorf("%q: expected io.EOF, got %v", line, err)
+		}
+	}
+}
+
+func TestDecoder_002(t *testing.T) {
+	tests := map[string]string{
+		"cpu":                   "line 1, column 4: Expected ' ' before end of line",
+		"cpu,host value=1":      "line 1, column 9: Expected '=' but found ' '",
+		"cpu,=a value=1":        "line 1, column 5: Missing tag key",
+		"cpu value=":            "line 1, column 11: Missing field value",
+		"cpu value=x":           "line 1, column 11: Invalid field value: x",
+		"cpu value=1 12x":       "line 1, column 13: Invalid timestamp: 12x",
+		"cpu value=\"abc":       "line 1, column 11: Unterminated string",
+		"\n\n cpu value=1 1 2":  "line 3, column 16: Unexpected character '2'",
+		"# comment\ncpu value=": "line 2, column 11: Missing field value",
+	}
+	for line, expected := range tests {
+		decoder := lineprotocol.NewDecoder(strings.NewReader(line))
+		if _, err := decoder.Decode(); err == nil {
+			t.Errorf("%q: expected error", line)
+		} else if _, ok := err.(*lineprotocol.SyntaxError); ok == false {
+			t.Errorf("%q: expected SyntaxError, got %v", line, err)
+		} else if err.Error() != expected {
+			t.Errorf("%q: expected %q, got %q", line, expected, err.Error())
+		}
+	}
+}
+
+func TestDecoder_003(t *testing.T) {
+	export := "# DDL\nCREATE DATABASE db WITH NAME autogen\n# DML\n# CONTEXT-DATABASE:db\ncpu v=1i 1\ncpu v=2i 2\n"
+	decoder := lineprotocol.NewDecoder(strings.NewReader(export))
+	decoder.SetPrecision(influxdb.PRECISION_SECOND)
+	for i := int64(1); i <= 2; i++ {
+		if point, err := decoder.Decode(); err != nil {
+			t.Error(err)
+		} else if point.Fields["v"] != i || point.Time.Equal(time.Unix(i, 0)) == false {
+			t.Error("Unexpected point", point)
+		}
+	}
+	if _, err := decoder.Decode(); err != io.EOF {
+		t.Error("Expected io.EOF, got", err)
+	}
+}
+
+func TestDecoder_004(t *testing.T) {
+	// Round trip through encoder and decoder
+	point := &influxdb.Point{
+		Name:   "a b,c\\d",
+		Tags:   map[string]string{"k=1": "v 1", "k,2": "v\\2"},
+		Fields: map[string]influxdb.Value{"f 1": "x\"y\\z", "f=2": int64(-3), "f,3": 1.25, "f4": true},
+		Time:   time.Unix(0, 123456789),
+	}
+	buf := new(bytes.Buffer)
+	encoder := lineprotocol.NewEncoder(buf)
+	encoder.SetPrecision(influxdb.PRECISION_NANO)
+	if err := encoder.EncodePoint(point); err != nil {
+		t.Fatal(err)
+	}
+	decoder := lineprotocol.NewDecoder(buf)
+	if point2, err := decoder.Decode(); err != nil {
+		t.Error(err)
+	} else if reflect.DeepEqual(point.Tags, point2.Tags) == false || reflect.DeepEqual(point.Fields, point2.Fields) == false || point.Name != point2.Name || point.Time.Equal(point2.Time) == false {
+		t.Errorf("Expected %+v, got %+v", point, point2)
+	}
+}
+
+func TestDecoder_005(t *testing.T) {
+	// Decoding continues on the line after a syntax error
+	decoder := lineprotocol.NewDecoder(strings.NewReader("cpu v=1\ncpu v=x y=2\ncpu,host v=3\ncpu v=4\n"))
+	values := make([]influxdb.Value, 0)
+	errs := make([]string, 0)
+	for {
+		if point, err := decoder.Decode(); err == io.EOF {
+			break
+		} else if err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			values = append(values, point.Fields["v"])
+		}
+	}
+	if len(values) != 2 || values[0] != 1.0 || values[1] != 4.0 {
+		t.Errorf("Unexpected values: %v", values)
+	} else if len(errs) != 2 || errs[0] != "line 2, column 7: Invalid field value: x" || errs[1] != "line 3, column 9: Expected '=' but found ' '" {
+		t.Errorf("Unexpected errors: %q", errs)
+	}
+}
+
+func TestDecoder_006(t *testing.T) {
+	// Syntax errors are returned together by DecodeDatasets
+	decoder := lineprotocol.NewDecoder(strings.NewReader("cpu\n\ncpu v=\n"))
+	if datasets, err := decoder.DecodeDatasets(nil, 0); len(datasets) != 0 {
+		t.Errorf("Unexpected datasets: %v", datasets)
+	} else if errs, ok := err.(lineprotocol.SyntaxErrors); ok == false || len(errs) != 2 {
+		t.Errorf("Expected SyntaxErrors, got %v", err)
+	} else if errs.Error() != "line 1, column 4: Expected ' ' before end of line\nline 3, column 7: Missing field value" {
+		t.Errorf("Unexpected error: %q", errs.Error())
+	} else if _, err := decoder.DecodeDatasets(nil, 0); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+/*
+	InfluxDB client
+	(c) Copyright David Thorpe 2017
+	All Rights Reserved
+
+	For Licensing and Usage information, please see LICENSE file
+*/
+
+package lineprotocol
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	influxdb "github.com/djthorpe/influxdb"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Encoder writes points in line protocol to an io.Writer. Tags and fields
+// are sorted by key, so that the series key for a point is canonical. An
+// encoder re-uses its buffers and is not safe for concurrent use
+type Encoder struct {
+	w         io.Writer
+	precision string
+	unit      int64
+	buf       []byte
+	keys      []string
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTRUCTOR
+
+// NewEncoder returns an encoder which writes to w with the default
+// precision
+func NewEncoder(w io.Writer) *Encoder {
+	this := new(Encoder)
+	this.w = w
+	this.buf = make([]byte, 0, 256)
+	this.keys = make([]string, 0, 16)
+	this.SetPrecision(influxdb.PRECISION_DEFAULT)
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Precision returns the precision used for timestamps
+func (this *Encoder) Precision() string {
+	return this.precision
+}
+
+// SetPrecision sets the precision which timestamps are truncated to,
+// which should be one of the PRECISION_ constants
+func (this *Encoder) SetPrecision(value string) error {
+	if unit := precisionUnit(value); unit == 0 {
+		return influxdb.ErrBadParameter
+	} else {
+		this.precision = value
+		this.unit = unit
+		return nil
+	}
+}
+
+// EncodePoint writes a single point
+func (this *Encoder) EncodePoint(point *influxdb.Point) error {
+	if point == nil {
+		return influxdb.ErrBadParameter
+	}
+	return this.Encode(point.Name, point.Tags, point.Fields, point.Time)
+}
+
+// Encode writes a single line with measurement name, tags, fields and
+// timestamp. Tags with empty values and fields with nil values are
+// omitted, and a zero timestamp is omitted so that the server sets it
+func (this *Encoder) Encode(name string, tags map[string]string, fields map[string]influxdb.Value, ts time.Time) error {
+	var err error
+	if this.buf, err = this.Append(this.buf[:0], name, tags, fields, ts); err != nil {
+		return err
+	}
+	_, err = this.w.Write(this.buf)
+	return err
+}
+
+// Append appends a single line to dst and returns the extended buffer
+func (this *Encoder) Append(dst []byte, name string, tags map[string]string, fields map[string]influxdb.Value, ts time.Time) ([]byte, error) {
+	if name == "" {
+		return dst, influxdb.ErrBadParameter
+	}
+	start := len(dst)
+	ok := true
+
+	// Measurement name
+	if dst, ok = appendEscaped(dst, name, escapeMeasurement); ok == false {
+		return dst[:start], influxdb.ErrBadParameter
+	}
+
+	// Tags in key order
+	this.keys = this.keys[:0]
+	for k, v := range tags {
+		if k == "" {
+			return dst[:start], influxdb.ErrBadParameter
+		} else if v != "" {
+			this.keys = append(this.keys, k)
+		}
+	}
+	sortStrings(this.keys)
+	for _, k := range this.keys {
+		dst = append(dst, ',')
+		if dst, ok = appendEscaped(dst, k, escapeKey); ok == false {
+			return dst[:start], influxdb.ErrBadParameter
+		}
+		dst = append(dst, '=')
+		if dst, ok = appendEscaped(dst, tags[k], escapeKey); ok == false {
+			return dst[:start], influxdb.ErrBadParameter
+		}
+	}
+
+	// Fields in key order
+	this.keys = this.keys[:0]
+	for k, v := range fields {
+		if k == "" {
+			return dst[:start], influxdb.ErrBadParameter
+		} else if v != nil {
+			this.keys = append(this.keys, k)
+		}
+	}
+	if len(this.keys) == 0 {
+		return dst[:start], influxdb.ErrBadParameter
+	}
+	sortStrings(this.keys)
+	for i, k := range this.keys {
+		if i == 0 {
+			dst = append(dst, ' ')
+		} else {
+			dst = append(dst, ',')
+		}
+		if dst, ok = appendEscaped(dst, k, escapeKey); ok == false {
+			return dst[:start], influxdb.ErrBadParameter
+		}
+		dst = append(dst, '=')
+		if dst, ok = this.appendValue(dst, fields[k]); ok == false {
+			return dst[:start], influxdb.ErrBadParameter
+		}
+	}
+
+	// Timestamp
+	if ts.IsZero() == false {
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, this.timestamp(ts), 10)
+	}
+
+	return append(dst, '\n'), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// appendValue appends a field value with the type suffix for integers
+// and quotes for strings. It returns false if the value cannot be encoded
+func (this *Encoder) appendValue(dst []byte, value influxdb.Value) ([]byte, bool) {
+	switch v := value.(type) {
+	case float64:
+		return appendFloat(dst, v)
+	case float32:
+		return appendFloat(dst, float64(v))
+	case int:
+		return appendInt(dst, int64(v)), true
+	case int8:
+		return appendInt(dst, int64(v)), true
+	case int16:
+		return appendInt(dst, int64(v)), true
+	case int32:
+		return appendInt(dst, int64(v)), true
+	case int64:
+		return appendInt(dst, v), true
+	case uint:
+		return appendUint(dst, uint64(v)), true
+	case uint8:
+		return appendUint(dst, uint64(v)), true
+	case uint16:
+		return appendUint(dst, uint64(v)), true
+	case uint32:
+		return appendUint(dst, uint64(v)), true
+	case uint64:
+		return appendUint(dst, v), true
+	case bool:
+		return strconv.AppendBool(dst, v), true
+	case string:
+		return appendString(dst, v), true
+	case []byte:
+		return appendString(dst, string(v)), true
+	case time.Duration:
+		return appendInt(dst, int64(v)), true
+	case time.Time:
+		return appendInt(dst, this.timestamp(v)), true
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return appendInt(dst, n), true
+		} else if n, err := v.Float64(); err == nil {
+			return appendFloat(dst, n)
+		} else {
+			return dst, false
+		}
+	default:
+		return dst, false
+	}
+}
+
+// timestamp returns the time truncated to the precision
+func (this *Encoder) timestamp(ts time.Time) int64 {
+	return ts.UnixNano() / this.unit
+}
+
+func appendFloat(dst []byte, v float64) ([]byte, bool) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return dst, false
+	}
+	return strconv.AppendFloat(dst, v, 'f', -1, 64), true
+}
+
+func appendInt(dst []byte, v int64) []byte {
+	return append(strconv.AppendInt(dst, v, 10), 'i')
+}
+
+func appendUint(dst []byte, v uint64) []byte {
+	return append(strconv.AppendUint(dst, v, 10), 'u')
+}
+
+// appendString appends a quoted string field value
+func appendString(dst []byte, v string) []byte {
+	dst = append(dst, '"')
+	dst, _ = appendEscaped(dst, v, escapeString)
+	return append(dst, '"')
+}
+
+// sortStrings sorts keys in place without allocating, which is faster
+// than sort.Strings for the small number of tags and fields in a point
+func sortStrings(keys []string) {
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j] < keys[j-1]; j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+}
+
+// precisionUnit returns the number of nanoseconds in one unit of
+// precision, or zero if the precision is invalid
+func precisionUnit(precision string) int64 {
+	switch precision {
+	case influxdb.PRECISION_NANO:
+		return int64(time.Nanosecond)
+	case influxdb.PRECISION_MICRO, influxdb.PRECISION_MICRO2:
+		return int64(time.Microsecond)
+	case influxdb.PRECISION_MILLI:
+		return int64(time.Millisecond)
+	case influxdb.PRECISION_SECOND:
+		return int64(time.Second)
+	case influxdb.PRECISION_MINUTE:
+		return int64(time.Minute)
+	case influxdb.PRECISION_HOUR:
+		return int64(time.Hour)
+	case influxdb.PRECISION_DAY:
+		return int64(time.Hour * 24)
+	case influxdb.PRECISION_WEEK:
+		return int64(time.Hour * 24 * 7)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,150 @@
+package lineprotocol_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/lineprotocol"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func TestEncoder_000(t *testing.T) {
+	buf := new(bytes.Buffer)
+	encoder := lineprotocol.NewEncoder(buf)
+	if encoder.Precision() != influxdb.PRECISION_DEFAULT {
+		t.Error("Unexpected precision:", encoder.Precision())
+	}
+	if err := encoder.SetPrecision("x"); err != influxdb.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+func TestEncoder_001(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		fields   map[string]influxdb.Value
+		expected string
+	}{
+		{"cpu", nil, map[string]influxdb.Value{"value": 1.5}, "cpu value=1.5\n"},
+		{"cpu", nil, map[string]influxdb.Value{"value": 1}, "cpu value=1i\n"},
+		{"cpu", nil, map[string]influxdb.Value{"value": uint32(1)}, "cpu value=1u\n"},
+		{"cpu", nil, map[string]influxdb.Value{"value": true}, "cpu value=true\n"},
+		{"cpu", nil, map[string]influxdb.Value{"value": "on"}, "cpu value=\"on\"\n"},
+		{"cpu", nil, map[string]influxdb.Value{"b": 2, "a": 1, "c": nil}, "cpu a=1i,b=2i\n"},
+		{"cpu", map[string]string{"z": "1", "a": "2", "m": ""}, map[string]influxdb.Value{"v": 1}, "cpu,a=2,z=1 v=1i\n"},
+		{"cpu load", nil, map[string]influxdb.Value{"v": 1}, "cpu\\ load v=1i\n"},
+		{"cpu,load", nil, map[string]influxdb.Value{"v": 1}, "cpu\\,load v=1i\n"},
+		{"cpu=load", nil, map[string]influxdb.Value{"v": 1}, "cpu=load v=1i\n"},
+		{"cpu", map[string]string{"host name": "a=b,c"}, map[string]influxdb.Value{"v": 1}, "cpu,host\\ name=a\\=b\\,c v=1i\n"},
+		{"cpu", nil, map[string]influxdb.Value{"a b=c": 1}, "cpu a\\ b\\=c=1i\n"},
+		{"cpu", nil, map[string]influxdb.Value{"v": "say \"hi\" \\o/"}, "cpu v=\"say \\\"hi\\\" \\\\o/\"\n"},
+		{"c\\pu", nil, map[string]influxdb.Value{"v": 1}, "c\\pu v=1i\n"},
+		{"cpu", map[string]string{"t\\k": "a\\b"}, map[string]influxdb.Value{"f\\1": 1}, "cpu,t\\k=a\\b f\\1=1i\n"},
+	}
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		encoder := lineprotocol.NewEncoder(buf)
+		if err := encoder.Encode(test.name, test.tags, test.fields, time.Time{}); err != nil {
+			t.Error(err)
+		} else if buf.String() != test.expected {
+			t.Errorf("Expected %q, got %q", test.expected, buf.String())
+		}
+	}
+}
+
+func TestEncoder_002(t *testing.T) {
+	ts := time.Unix(1500000000, 123456789)
+	tests := map[string]string{
+		influxdb.PRECISION_NANO:   "1500000000123456789",
+		influxdb.PRECISION_MICRO:  "1500000000123456",
+		influxdb.PRECISION_MICRO2: "1500000000123456",
+		influxdb.PRECISION_MILLI:  "1500000000123",
+		influxdb.PRECISION_SECOND: "1500000000",
+		influxdb.PRECISION_MINUTE: "25000000",
+		influxdb.PRECISION_HOUR:   "416666",
+		influxdb.PRECISION_DAY:    "17361",
+		influxdb.PRECISION_WEEK:   "2480",
+	}
+	for precision, expected := range tests {
+		buf := new(bytes.Buffer)
+		encoder := lineprotocol.NewEncoder(buf)
+		if err := encoder.SetPrecision(precision); err != nil {
+			t.Error(err)
+		} else if err := encoder.Encode("cpu", nil, map[string]influxdb.Value{"v": 1}, ts); err != nil {
+			t.Error(err)
+		} else if buf.String() != "cpu v=1i "+expected+"\n" {
+			t.Errorf("For precision %v, got %q", precision, buf.String())
+		}
+	}
+}
+
+func TestEncoder_003(t *testing.T) {
+	encoder := lineprotocol.NewEncoder(ioutil.Discard)
+	if err := encoder.Encode("", nil, map[string]influxdb.Value{"v": 1}, time.Time{}); err != influxdb.ErrBadParameter {
+		t.Error("Expected ErrBadParameter for empty name, got", err)
+	}
+	if err := encoder.Encode("cpu", nil, map[string]influxdb.Value{"v": nil}, time.Time{}); err != influxdb.ErrBadParameter {
+		t.Error("Expected ErrBadParameter for no fields, got", err)
+	}
+	if err := encoder.Encode("cpu", nil, map[string]influxdb.Value{"v": struct{}{}}, time.Time{}); err != influxdb.ErrBadParameter {
+		t.Error("Expected ErrBadParameter for invalid field, got", err)
+	}
+	if err := encoder.Encode("cpu\n", nil, map[string]influxdb.Value{"v": 1}, time.Time{}); err != influxdb.ErrBadParameter {
+		t.Error("Expected ErrBadParameter for newline, got", err)
+	}
+}
+
+func BenchmarkEncoder_000(b *testing.B) {
+	encoder := lineprotocol.NewEncoder(ioutil.Discard)
+	tags := map[string]string{"host": "rpi3", "region": "uk", "sensor": "bme280"}
+	fields := map[string]influxdb.Value{"temperature": 21.5, "humidity": 45, "pressure": uint(1013)}
+	ts := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := encoder.Encode("environment", tags, fields, ts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEncoder_004(t *testing.T) {
+	// Backslashes are decoded as they were encoded
+	tests := []struct {
+		name string
+		tags map[string]string
+	}{
+		{"a\\ b", map[string]string{"a\\ b": "a\\ b"}},
+		{"a\\,b", map[string]string{"a\\=b": "a\\,b\\c"}},
+	}
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		encoder := lineprotocol.NewEncoder(buf)
+		if err := encoder.Encode(test.name, test.tags, map[string]influxdb.Value{"v": 1}, time.Time{}); err != nil {
+			t.Error(err)
+		} else if point, err := lineprotocol.NewDecoder(buf).Decode(); err != nil {
+			t.Errorf("%q: %v", buf.String(), err)
+		} else if point.Name != test.name || reflect.DeepEqual(point.Tags, test.tags) == false {
+			t.Errorf("Expected %q %v, got %q %v", test.name, test.tags, point.Name, point.Tags)
+		}
+	}
+
+	// A trailing backslash would escape the separator which follows
+	for _, test := range []struct {
+		name string
+		tags map[string]string
+	}{
+		{"foo\\", nil},
+		{"cpu", map[string]string{"foo\\": "a"}},
+		{"cpu", map[string]string{"host": "foo\\", "region": "x"}},
+	} {
+		if err := lineprotocol.NewEncoder(ioutil.Discard).Encode(test.name, test.tags, map[string]influxdb.Value{"v": 1}, time.Time{}); err != influxdb.ErrBadParameter {
+			t.Errorf("%q %v: Expected ErrBadParameter, got %v", test.name, test.tags, err)
+		}
+	}
+}
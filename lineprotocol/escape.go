@@ -0,0 +1,75 @@
+/*
+	InfluxDB client
+	(c) Copyright David Thorpe 2017
+	All Rights Reserved
+
+	For Licensing and Usage information, please see LICENSE file
+*/
+
+package lineprotocol
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// escapeTable defines how each byte is written
+type escapeTable [256]uint8
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	escapeNone uint8 = iota
+	escapeBackslash
+	escapeInvalid
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Measurement names escape commas and spaces. Backslashes are
+	// written as they are, except that a name cannot end with one,
+	// as it would escape the separator which follows
+	escapeMeasurement = newEscapeTable(", ", "\n\r")
+
+	// Tag keys, tag values and field keys also escape equals signs
+	escapeKey = newEscapeTable(",= ", "\n\r")
+
+	// String field values escape double quotes and backslashes
+	escapeString = newEscapeTable("\"\\", "")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func newEscapeTable(escape, invalid string) *escapeTable {
+	table := new(escapeTable)
+	for i := 0; i < len(escape); i++ {
+		table[escape[i]] = escapeBackslash
+	}
+	for i := 0; i < len(invalid); i++ {
+		table[invalid[i]] = escapeInvalid
+	}
+	return table
+}
+
+// appendEscaped appends value to dst with a backslash before each
+// character which needs escaping. It returns false if the value contains
+// characters which cannot be represented, or ends with a backslash which
+// is not escaped
+func appendEscaped(dst []byte, value string, table *escapeTable) ([]byte, bool) {
+	if n := len(value); n > 0 && value[n-1] == '\\' && table['\\'] == escapeNone {
+		return dst, false
+	}
+	for i := 0; i < len(value); i++ {
+		switch table[value[i]] {
+		case escapeBackslash:
+			dst = append(dst, '\\', value[i])
+		case escapeInvalid:
+			return dst, false
+		default:
+			dst = append(dst, value[i])
+		}
+	}
+	return dst, true
+}
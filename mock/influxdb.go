@@ -8,6 +8,8 @@
 package mock
 
 import (
+	"time"
+
 	"github.com/djthorpe/gopi"
 	"github.com/djthorpe/influxdb"
 )
@@ -50,12 +52,10 @@ func (config Config) Open(log gopi.Logger) (gopi.Driver, error) {
 	}
 
 	// Set precision
-	if config.Precision != "" {
-		if err := this.SetPrecision(config.Precision); err != nil {
-			return nil, err
-		} else {
-			this.SetPrecision(influxdb.PRECISION_DEFAULT)
-		}
+	if config.Precision == "" {
+		this.SetPrecision(influxdb.PRECISION_DEFAULT)
+	} else if err := this.SetPrecision(config.Precision); err != nil {
+		return nil, err
 	}
 
 	// Return success
@@ -154,6 +154,172 @@ func (this *Driver) DropRetentionPolicy(name string) error {
 	return influxdb.ErrNotSupported
 }
 
+func (this *Driver) RetentionPolicies() (map[string]*influxdb.RetentionPolicy, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONTINUOUS QUERIES
+
+func (this *Driver) ContinuousQueries() ([]*influxdb.ContinuousQuery, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) CreateContinuousQuery(name string, query influxdb.Query, every, for_ time.Duration) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
+func (this *Driver) DropContinuousQuery(name string) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SCHEMA
+
+func (this *Driver) Schema() (influxdb.Schema, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) Stats() (*influxdb.Stats, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) Diagnostics() (*influxdb.Diagnostics, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) Shards() ([]*influxdb.Shard, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) ShardGroups() ([]*influxdb.ShardGroup, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) DropShard(id uint64) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
+func (this *Driver) Queries() ([]*influxdb.QueryInfo, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) KillQuery(id uint64) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
+func (this *Driver) Cardinality(query influxdb.Query) (uint64, error) {
+	if this.connected == false {
+		return 0, influxdb.ErrNotConnected
+	}
+	return 0, influxdb.ErrNotSupported
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// USERS AND PRIVILEGES
+
+func (this *Driver) Users() ([]*influxdb.User, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) CreateUser(name, password string, admin bool) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
+func (this *Driver) DropUser(name string) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
+func (this *Driver) SetPassword(name, password string) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
+func (this *Driver) Grants(name string) ([]*influxdb.Privilege, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) Grant(name, database, privilege string) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
+func (this *Driver) Revoke(name, database, privilege string) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// WRITE DATA
+
+func (this *Driver) NewDataset(name string, tags, fields []string) (influxdb.Dataset, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) Write(dataset influxdb.Dataset) error {
+	if this.connected == false {
+		return influxdb.ErrNotConnected
+	}
+	return influxdb.ErrNotSupported
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PERFORM QUERY
 
@@ -161,6 +327,34 @@ func (this *Driver) Do(query influxdb.Query) (influxdb.Results, error) {
 	if this.connected == false {
 		return nil, influxdb.ErrNotConnected
 	}
+	if err := query.Err(); err != nil {
+		return nil, err
+	}
 	this.log.Debug2("Do(%v)", query.String())
 	return nil, influxdb.ErrNotSupported
 }
+
+func (this *Driver) DoWithParams(query influxdb.Query, params map[string]interface{}) (influxdb.Results, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	if err := query.Err(); err != nil {
+		return nil, err
+	}
+	this.log.Debug2("DoWithParams(%v, %v)", query.String(), params)
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) DoBatch(queries ...influxdb.Query) ([]influxdb.Results, error) {
+	if this.connected == false {
+		return nil, influxdb.ErrNotConnected
+	}
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *Driver) Backfill(query influxdb.Query, from, to time.Time, chunk time.Duration, filter ...influxdb.Predicate) (uint64, error) {
+	if this.connected == false {
+		return 0, influxdb.ErrNotConnected
+	}
+	return 0, influxdb.ErrNotSupported
+}
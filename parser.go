@@ -0,0 +1,1461 @@
+/*
+	InfluxDB client
+	(c) Copyright David Thorpe 2017
+	All Rights Reserved
+
+	For Licensing and Usage information, please see LICENSE file
+*/
+
+package influxdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type tokenType uint
+
+type token struct {
+	t     tokenType
+	value string
+	pos   int
+	end   int
+}
+
+// parser reads InfluxQL statements into queries and predicates. The
+// statement is split into tokens before parsing, and words in the
+// reserved words table are keywords which must be quoted to be used
+// as identifiers
+type parser struct {
+	query  string
+	tokens []token
+	i      int
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	token_EOF      tokenType = iota
+	token_IDENT              // bare identifier
+	token_QUOTED             // double-quoted identifier
+	token_KEYWORD            // reserved word, in upper case
+	token_STRING             // single-quoted string
+	token_NUMBER             // integer or float
+	token_DURATION           // duration literal such as 1h30m
+	token_REGEX              // regular expression without the slashes
+	token_OPERATOR           // comparison or arithmetic operator
+	token_PUNCT              // ( ) , . * ;
+	token_PARAM              // bound parameter name without the $
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ParseQuery returns a query for an InfluxQL statement. The query
+// returns the statement in canonical form from String, and can be
+// modified before it is executed. Errors are returned as *ParseError
+func ParseQuery(value string) (Query, error) {
+	this, err := newParser(value)
+	if err != nil {
+		return nil, err
+	}
+	query, err := this.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+	this.accept(token_PUNCT, ";")
+	if this.peek().t != token_EOF {
+		return nil, this.expected("EOF")
+	}
+	return query, nil
+}
+
+// ParsePredicate returns a predicate for an InfluxQL condition, as used
+// in a WHERE clause. Errors are returned as *ParseError
+func ParsePredicate(value string) (Predicate, error) {
+	this, err := newParser(value)
+	if err != nil {
+		return nil, err
+	}
+	predicate, err := this.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+	if this.peek().t != token_EOF {
+		return nil, this.expected("AND, OR or EOF")
+	}
+	return predicate, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STATEMENTS
+
+func (this *parser) parseStatement() (Query, error) {
+	tok := this.peek()
+	switch {
+	case tok.isKeyword("SELECT"):
+		return this.parseSelect()
+	case tok.isKeyword("SHOW"):
+		this.next()
+		return this.parseShow()
+	case tok.isKeyword("CREATE"):
+		this.next()
+		return this.parseCreate()
+	case tok.isKeyword("ALTER"):
+		this.next()
+		return this.parseAlter()
+	case tok.isKeyword("DROP"):
+		this.next()
+		return this.parseDrop()
+	case tok.isKeyword("DELETE"):
+		this.next()
+		q := &q_Delete{}
+		if this.acceptKeyword("FROM") {
+			if measurement, err := this.parseMeasurement(); err != nil {
+				return nil, err
+			} else {
+				q.measurement = measurement
+			}
+		}
+		if where, err := this.parseWhere(); err != nil {
+			return nil, err
+		} else {
+			q.where = where
+		}
+		return q, nil
+	case tok.isKeyword("GRANT"), tok.isKeyword("REVOKE"):
+		return this.parseGrant()
+	case tok.isKeyword("KILL"):
+		this.next()
+		if err := this.expectKeyword("QUERY"); err != nil {
+			return nil, err
+		} else if id, err := this.parseUint("query id"); err != nil {
+			return nil, err
+		} else {
+			return KillQuery(id), nil
+		}
+	case tok.isKeyword("SET"):
+		this.next()
+		if err := this.expectKeyword("PASSWORD", "FOR"); err != nil {
+			return nil, err
+		} else if name, err := this.parseIdent("user name"); err != nil {
+			return nil, err
+		} else if err := this.expect(token_OPERATOR, "="); err != nil {
+			return nil, err
+		} else if password, err := this.parseString("password"); err != nil {
+			return nil, err
+		} else {
+			return SetPassword(name, password), nil
+		}
+	default:
+		return nil, this.expected("SELECT, SHOW, CREATE, ALTER, DROP, DELETE, GRANT, REVOKE, KILL or SET")
+	}
+}
+
+func (this *parser) parseSelect() (*q_Select, error) {
+	if err := this.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	q := &q_Select{}
+
+	// Columns
+	for {
+		if column, err := this.parseColumn(); err != nil {
+			return nil, err
+		} else {
+			q.columns = append(q.columns, column)
+		}
+		if this.accept(token_PUNCT, ",") == false {
+			break
+		}
+	}
+
+	// Target and sources
+	if this.acceptKeyword("INTO") {
+		if into, err := this.parseMeasurement(); err != nil {
+			return nil, err
+		} else {
+			q.into = into
+		}
+	}
+	if err := this.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	for {
+		if this.accept(token_PUNCT, "(") {
+			if subquery, err := this.parseSelect(); err != nil {
+				return nil, err
+			} else if err := this.expect(token_PUNCT, ")"); err != nil {
+				return nil, err
+			} else {
+				q.measurement = append(q.measurement, Subquery(subquery))
+			}
+		} else if measurement, err := this.parseMeasurement(); err != nil {
+			return nil, err
+		} else {
+			q.measurement = append(q.measurement, measurement)
+		}
+		if this.accept(token_PUNCT, ",") == false {
+			break
+		}
+	}
+
+	// Conditions
+	if where, err := this.parseWhere(); err != nil {
+		return nil, err
+	} else {
+		q.where = where
+	}
+
+	// Grouping
+	if this.acceptKeyword("GROUP", "BY") {
+		for {
+			if dimension, err := this.parseDimension(); err != nil {
+				return nil, err
+			} else {
+				q.groupby = append(q.groupby, dimension)
+			}
+			if this.accept(token_PUNCT, ",") == false {
+				break
+			}
+		}
+	}
+	if this.peek().isKeyword("FILL") && this.peekAt(1).is(token_PUNCT, "(") {
+		this.next()
+		this.next()
+		if value, err := this.parseFill(); err != nil {
+			return nil, err
+		} else if err := this.expect(token_PUNCT, ")"); err != nil {
+			return nil, err
+		} else {
+			q.Fill(value)
+		}
+	}
+
+	// Ordering
+	if this.acceptKeyword("ORDER", "BY") {
+		if err := this.expectKeyword("TIME"); err != nil {
+			return nil, err
+		} else if this.acceptKeyword("DESC") {
+			q.descending = true
+		} else {
+			this.acceptKeyword("ASC")
+		}
+	}
+
+	// Limits
+	if err := this.parseLimitOffset("LIMIT", &q.limit, "OFFSET", &q.offset); err != nil {
+		return nil, err
+	}
+	if err := this.parseLimitOffset("SLIMIT", &q.slimit, "SOFFSET", &q.soffset); err != nil {
+		return nil, err
+	}
+
+	// Timezone
+	if this.peek().isKeyword("TZ") && this.peekAt(1).is(token_PUNCT, "(") {
+		this.next()
+		this.next()
+		if tz, err := this.parseString("time zone"); err != nil {
+			return nil, err
+		} else if err := this.expect(token_PUNCT, ")"); err != nil {
+			return nil, err
+		} else {
+			q.tz = tz
+		}
+	}
+
+	return q, nil
+}
+
+func (this *parser) parseShow() (Query, error) {
+	switch {
+	case this.acceptKeyword("DATABASES"):
+		return ShowDatabases(), nil
+	case this.acceptKeyword("MEASUREMENTS"):
+		return this.parseShowMeasurements()
+	case this.acceptKeyword("MEASUREMENT"):
+		return this.parseCardinality("MEASUREMENT")
+	case this.acceptKeyword("SERIES"):
+		if this.peek().isKeyword("EXACT") || this.peek().isKeyword("CARDINALITY") {
+			return this.parseCardinality("SERIES")
+		}
+		return this.parseShowSeries()
+	case this.acceptKeyword("TAG", "KEYS"):
+		return this.parseShowTagKeys()
+	case this.acceptKeyword("TAG", "KEY"):
+		return this.parseCardinality("TAG KEY")
+	case this.acceptKeyword("TAG", "VALUES"):
+		if this.peek().isKeyword("EXACT") || this.peek().isKeyword("CARDINALITY") {
+			return this.parseCardinality("TAG VALUES")
+		}
+		return this.parseShowTagValues()
+	case this.acceptKeyword("FIELD", "KEYS"):
+		return this.parseShowFieldKeys()
+	case this.acceptKeyword("FIELD", "KEY"):
+		return this.parseCardinality("FIELD KEY")
+	case this.acceptKeyword("RETENTION", "POLICIES"):
+		q := &q_ShowRetentionPolicies{}
+		if err := this.parseOn(&q.database); err != nil {
+			return nil, err
+		}
+		return q, nil
+	case this.acceptKeyword("CONTINUOUS", "QUERIES"):
+		return ShowContinuousQueries(), nil
+	case this.acceptKeyword("USERS"):
+		return ShowUsers(), nil
+	case this.acceptKeyword("GRANTS"):
+		if err := this.expectKeyword("FOR"); err != nil {
+			return nil, err
+		} else if name, err := this.parseIdent("user name"); err != nil {
+			return nil, err
+		} else {
+			return ShowGrants(name), nil
+		}
+	case this.acceptKeyword("QUERIES"):
+		return ShowQueries(), nil
+	case this.acceptKeyword("SHARDS"):
+		return ShowShards(), nil
+	case this.acceptKeyword("SHARD", "GROUPS"):
+		return ShowShardGroups(), nil
+	case this.acceptKeyword("STATS"):
+		if this.acceptKeyword("FOR") {
+			if module, err := this.parseString("module"); err != nil {
+				return nil, err
+			} else {
+				return ShowStats(module), nil
+			}
+		}
+		return ShowStats(""), nil
+	case this.acceptKeyword("DIAGNOSTICS"):
+		return ShowDiagnostics(), nil
+	default:
+		return nil, this.expected("DATABASES, MEASUREMENTS, SERIES, TAG, FIELD, RETENTION, CONTINUOUS, USERS, GRANTS, QUERIES, SHARDS, SHARD, STATS or DIAGNOSTICS")
+	}
+}
+
+func (this *parser) parseShowMeasurements() (Query, error) {
+	q := &q_ShowMeasurements{}
+	if err := this.parseOn(&q.database); err != nil {
+		return nil, err
+	}
+	if this.acceptKeyword("WITH", "MEASUREMENT") {
+		if this.accept(token_OPERATOR, "=~") {
+			if tok := this.peek(); tok.t != token_REGEX {
+				return nil, this.expected("regular expression")
+			}
+		} else if err := this.expect(token_OPERATOR, "="); err != nil {
+			return nil, err
+		}
+		if measurement, err := this.parseMeasurement(); err != nil {
+			return nil, err
+		} else {
+			q.measurement = measurement
+		}
+	}
+	if where, err := this.parseWhere(); err != nil {
+		return nil, err
+	} else {
+		q.where = where
+	}
+	if err := this.parseLimitOffset("LIMIT", &q.limit, "OFFSET", &q.offset); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (this *parser) parseShowSeries() (Query, error) {
+	q := &q_ShowSeries{}
+	if err := this.parseOn(&q.database); err != nil {
+		return nil, err
+	} else if err := this.parseFrom(&q.measurement); err != nil {
+		return nil, err
+	} else if where, err := this.parseWhere(); err != nil {
+		return nil, err
+	} else {
+		q.where = where
+	}
+	if err := this.parseLimitOffset("LIMIT", &q.limit, "OFFSET", &q.offset); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (this *parser) parseShowTagKeys() (Query, error) {
+	q := &q_ShowTagKeys{}
+	if err := this.parseOn(&q.database); err != nil {
+		return nil, err
+	} else if err := this.parseFrom(&q.measurement); err != nil {
+		return nil, err
+	} else if where, err := this.parseWhere(); err != nil {
+		return nil, err
+	} else {
+		q.where = where
+	}
+	if err := this.parseLimitOffset("LIMIT", &q.limit, "OFFSET", &q.offset); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (this *parser) parseShowTagValues() (Query, error) {
+	q := &q_ShowTagValues{}
+	if err := this.parseOn(&q.database); err != nil {
+		return nil, err
+	} else if err := this.parseFrom(&q.measurement); err != nil {
+		return nil, err
+	} else if err := this.expectKeyword("WITH", "KEY"); err != nil {
+		return nil, err
+	}
+	if this.acceptKeyword("IN") {
+		if err := this.expect(token_PUNCT, "("); err != nil {
+			return nil, err
+		}
+		for {
+			if key, err := this.parseIdent("tag key"); err != nil {
+				return nil, err
+			} else {
+				q.keys = append(q.keys, key)
+			}
+			if this.accept(token_PUNCT, ",") == false {
+				break
+			}
+		}
+		if err := this.expect(token_PUNCT, ")"); err != nil {
+			return nil, err
+		}
+	} else if err := this.expect(token_OPERATOR, "="); err != nil {
+		return nil, err
+	} else if key, err := this.parseIdent("tag key"); err != nil {
+		return nil, err
+	} else {
+		q.keys = []string{key}
+	}
+	if where, err := this.parseWhere(); err != nil {
+		return nil, err
+	} else {
+		q.where = where
+	}
+	if err := this.parseLimitOffset("LIMIT", &q.limit, "OFFSET", &q.offset); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (this *parser) parseShowFieldKeys() (Query, error) {
+	q := &q_ShowFieldKeys{}
+	if err := this.parseOn(&q.database); err != nil {
+		return nil, err
+	} else if err := this.parseFrom(&q.measurement); err != nil {
+		return nil, err
+	} else if err := this.parseLimitOffset("LIMIT", &q.limit, "OFFSET", &q.offset); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (this *parser) parseCardinality(what string) (Query, error) {
+	q := &q_ShowCardinality{what: what}
+	q.exact = this.acceptKeyword("EXACT")
+	if err := this.expectKeyword("CARDINALITY"); err != nil {
+		return nil, err
+	} else if err := this.parseOn(&q.database); err != nil {
+		return nil, err
+	} else if err := this.parseFrom(&q.measurement); err != nil {
+		return nil, err
+	}
+	if this.acceptKeyword("WITH", "KEY") {
+		if err := this.expect(token_OPERATOR, "="); err != nil {
+			return nil, err
+		} else if key, err := this.parseIdent("tag key"); err != nil {
+			return nil, err
+		} else {
+			q.key = key
+		}
+	}
+	if where, err := this.parseWhere(); err != nil {
+		return nil, err
+	} else {
+		q.where = where
+	}
+	return q, nil
+}
+
+func (this *parser) parseCreate() (Query, error) {
+	switch {
+	case this.acceptKeyword("DATABASE"):
+		name, err := this.parseIdent("database name")
+		if err != nil {
+			return nil, err
+		}
+		q := &q_CreateDatabase{database: name, policyName: "autogen"}
+		if this.acceptKeyword("WITH") {
+			q.policy = &RetentionPolicy{}
+			if err := this.parsePolicy(q.policy); err != nil {
+				return nil, err
+			}
+			if this.acceptKeyword("NAME") {
+				if q.policyName, err = this.parseIdent("policy name"); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return q, nil
+	case this.acceptKeyword("RETENTION", "POLICY"):
+		q := &q_CreateRetentionPolicy{policy: &RetentionPolicy{}}
+		if name, err := this.parseIdent("policy name"); err != nil {
+			return nil, err
+		} else if err := this.parseOn(&q.database); err != nil {
+			return nil, err
+		} else if err := this.parsePolicy(q.policy); err != nil {
+			return nil, err
+		} else {
+			q.name = name
+			q.defalt = this.acceptKeyword("DEFAULT")
+		}
+		return q, nil
+	case this.acceptKeyword("CONTINUOUS", "QUERY"):
+		q := &q_CreateContinuousQuery{}
+		if name, err := this.parseIdent("continuous query name"); err != nil {
+			return nil, err
+		} else if err := this.parseOn(&q.database); err != nil {
+			return nil, err
+		} else {
+			q.name = name
+		}
+		if this.acceptKeyword("RESAMPLE") {
+			if this.acceptKeyword("EVERY") {
+				if every, err := this.parseDuration(); err != nil {
+					return nil, err
+				} else {
+					q.every = every
+				}
+			}
+			if this.acceptKeyword("FOR") {
+				if for_, err := this.parseDuration(); err != nil {
+					return nil, err
+				} else {
+					q.for_ = for_
+				}
+			}
+		}
+		if err := this.expectKeyword("BEGIN"); err != nil {
+			return nil, err
+		} else if query, err := this.parseSelect(); err != nil {
+			return nil, err
+		} else if err := this.expectKeyword("END"); err != nil {
+			return nil, err
+		} else {
+			q.query = query
+		}
+		return q, nil
+	case this.acceptKeyword("USER"):
+		if name, err := this.parseIdent("user name"); err != nil {
+			return nil, err
+		} else if err := this.expectKeyword("WITH", "PASSWORD"); err != nil {
+			return nil, err
+		} else if password, err := this.parseString("password"); err != nil {
+			return nil, err
+		} else {
+			admin := this.acceptKeyword("WITH", "ALL", "PRIVILEGES")
+			return CreateUser(name, password, admin), nil
+		}
+	default:
+		return nil, this.expected("DATABASE, RETENTION, CONTINUOUS or USER")
+	}
+}
+
+func (this *parser) parseAlter() (Query, error) {
+	if err := this.expectKeyword("RETENTION", "POLICY"); err != nil {
+		return nil, err
+	}
+	q := &q_AlterRetentionPolicy{policy: &RetentionPolicy{}}
+	if name, err := this.parseIdent("policy name"); err != nil {
+		return nil, err
+	} else if err := this.parseOn(&q.database); err != nil {
+		return nil, err
+	} else if err := this.parsePolicy(q.policy); err != nil {
+		return nil, err
+	} else {
+		q.name = name
+		q.defalt = this.acceptKeyword("DEFAULT")
+	}
+	return q, nil
+}
+
+func (this *parser) parseDrop() (Query, error) {
+	switch {
+	case this.acceptKeyword("DATABASE"):
+		if name, err := this.parseIdent("database name"); err != nil {
+			return nil, err
+		} else {
+			return DropDatabase(name), nil
+		}
+	case this.acceptKeyword("MEASUREMENT"):
+		if name, err := this.parseIdent("measurement name"); err != nil {
+			return nil, err
+		} else {
+			return DropMeasurement(name), nil
+		}
+	case this.acceptKeyword("SERIES"):
+		q := &q_DropSeries{}
+		if err := this.parseFrom(&q.measurement); err != nil {
+			return nil, err
+		} else if where, err := this.parseWhere(); err != nil {
+			return nil, err
+		} else {
+			q.where = where
+		}
+		return q, nil
+	case this.acceptKeyword("SHARD"):
+		if id, err := this.parseUint("shard id"); err != nil {
+			return nil, err
+		} else {
+			return DropShard(id), nil
+		}
+	case this.acceptKeyword("CONTINUOUS", "QUERY"):
+		q := &q_DropContinuousQuery{}
+		if name, err := this.parseIdent("continuous query name"); err != nil {
+			return nil, err
+		} else if err := this.parseOn(&q.database); err != nil {
+			return nil, err
+		} else {
+			q.name = name
+		}
+		return q, nil
+	case this.acceptKeyword("RETENTION", "POLICY"):
+		q := &q_DropRetentionPolicy{}
+		if name, err := this.parseIdent("policy name"); err != nil {
+			return nil, err
+		} else if err := this.parseOn(&q.database); err != nil {
+			return nil, err
+		} else {
+			q.name = name
+		}
+		return q, nil
+	case this.acceptKeyword("USER"):
+		if name, err := this.parseIdent("user name"); err != nil {
+			return nil, err
+		} else {
+			return DropUser(name), nil
+		}
+	default:
+		return nil, this.expected("DATABASE, MEASUREMENT, SERIES, SHARD, CONTINUOUS, RETENTION or USER")
+	}
+}
+
+func (this *parser) parseGrant() (Query, error) {
+	q := &q_Grant{revoke: this.next().isKeyword("REVOKE")}
+	switch {
+	case this.acceptKeyword("READ"):
+		q.privilege = PRIVILEGE_READ
+	case this.acceptKeyword("WRITE"):
+		q.privilege = PRIVILEGE_WRITE
+	case this.acceptKeyword("ALL"):
+		this.acceptKeyword("PRIVILEGES")
+		q.privilege = PRIVILEGE_ALL
+	default:
+		return nil, this.expected("READ, WRITE or ALL")
+	}
+	if err := this.parseOn(&q.database); err != nil {
+		return nil, err
+	}
+	if q.revoke {
+		if err := this.expectKeyword("FROM"); err != nil {
+			return nil, err
+		}
+	} else if err := this.expectKeyword("TO"); err != nil {
+		return nil, err
+	}
+	if name, err := this.parseIdent("user name"); err != nil {
+		return nil, err
+	} else {
+		q.name = name
+	}
+	return q, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CLAUSES
+
+// parseOn parses an optional ON clause
+func (this *parser) parseOn(database *string) error {
+	if this.acceptKeyword("ON") {
+		if name, err := this.parseIdent("database name"); err != nil {
+			return err
+		} else {
+			*database = name
+		}
+	}
+	return nil
+}
+
+// parseFrom parses an optional FROM clause with a single measurement
+func (this *parser) parseFrom(measurement **Measurement) error {
+	if this.acceptKeyword("FROM") {
+		if m, err := this.parseMeasurement(); err != nil {
+			return err
+		} else {
+			*measurement = m
+		}
+	}
+	return nil
+}
+
+// parseWhere parses an optional WHERE clause, and returns the conditions
+// which are joined with AND
+func (this *parser) parseWhere() ([]Predicate, error) {
+	if this.acceptKeyword("WHERE") == false {
+		return nil, nil
+	} else if predicate, err := this.parseCondition(); err != nil {
+		return nil, err
+	} else if and, ok := predicate.(*p_And); ok {
+		return and.values, nil
+	} else {
+		return []Predicate{predicate}, nil
+	}
+}
+
+// parseLimitOffset parses optional LIMIT and OFFSET clauses
+func (this *parser) parseLimitOffset(limit_keyword string, limit *uint, offset_keyword string, offset *uint) error {
+	if this.acceptKeyword(limit_keyword) {
+		if n, err := this.parseUint("number"); err != nil {
+			return err
+		} else {
+			*limit = uint(n)
+		}
+	}
+	if this.acceptKeyword(offset_keyword) {
+		if n, err := this.parseUint("number"); err != nil {
+			return err
+		} else {
+			*offset = uint(n)
+		}
+	}
+	return nil
+}
+
+// parsePolicy parses retention policy durations and replication
+func (this *parser) parsePolicy(policy *RetentionPolicy) error {
+	for {
+		switch {
+		case this.acceptKeyword("DURATION"):
+			if this.acceptKeyword("INF") {
+				policy.Duration = 0
+			} else if duration, err := this.parseDuration(); err != nil {
+				return err
+			} else {
+				policy.Duration = duration
+			}
+		case this.acceptKeyword("REPLICATION"):
+			if n, err := this.parseUint("replication factor"); err != nil {
+				return err
+			} else {
+				policy.ReplicationFactor = int(n)
+			}
+		case this.acceptKeyword("SHARD", "DURATION"):
+			if duration, err := this.parseDuration(); err != nil {
+				return err
+			} else {
+				policy.ShardGroupDuration = duration
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// parseMeasurement parses a measurement name, which can be qualified
+// with a retention policy and database, or a regular expression
+func (this *parser) parseMeasurement() (*Measurement, error) {
+	segments := make([]string, 0, 3)
+	regexp := false
+FOR_LOOP:
+	for {
+		tok := this.peek()
+		switch {
+		case tok.is(token_PUNCT, ".") && len(segments) > 0:
+			segments = append(segments, "")
+		case tok.t == token_IDENT || tok.t == token_QUOTED:
+			this.next()
+			segments = append(segments, tok.value)
+		case tok.t == token_REGEX:
+			this.next()
+			segments = append(segments, tok.value)
+			regexp = true
+			break FOR_LOOP
+		default:
+			return nil, this.expected("measurement")
+		}
+		if len(segments) == 3 || this.accept(token_PUNCT, ".") == false {
+			break
+		}
+	}
+	m := &Measurement{Regexp: regexp}
+	switch len(segments) {
+	case 1:
+		m.Name = segments[0]
+	case 2:
+		m.Policy, m.Name = segments[0], segments[1]
+	case 3:
+		m.Database, m.Policy, m.Name = segments[0], segments[1], segments[2]
+	}
+	return m, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// COLUMNS AND DIMENSIONS
+
+func (this *parser) parseColumn() (Predicate, error) {
+	value, err := this.parseField()
+	if err != nil {
+		return nil, err
+	}
+	if this.acceptKeyword("AS") {
+		if alias, err := this.parseIdent("alias"); err != nil {
+			return nil, err
+		} else {
+			return As(value, alias), nil
+		}
+	}
+	return value, nil
+}
+
+// parseField parses a field, a wildcard or a function call
+func (this *parser) parseField() (Predicate, error) {
+	tok := this.peek()
+	switch {
+	case tok.is(token_PUNCT, "*"):
+		this.next()
+		return Field("*"), nil
+	case tok.t == token_IDENT && this.peekAt(1).is(token_PUNCT, "("):
+		return this.parseFunction()
+	case tok.t == token_IDENT || tok.t == token_QUOTED:
+		this.next()
+		return Field(tok.value), nil
+	default:
+		return nil, this.expected("field or function")
+	}
+}
+
+// parseFunction parses a function call, where the first argument can be
+// a field or another function and other arguments are literals
+func (this *parser) parseFunction() (Predicate, error) {
+	f := &p_Function{name: strings.ToUpper(this.next().value)}
+	this.next()
+	if this.accept(token_PUNCT, ")") {
+		return f, nil
+	}
+	for {
+		if tok := this.peek(); len(f.args) == 0 && f.value == nil && (tok.is(token_PUNCT, "*") || tok.t == token_IDENT || tok.t == token_QUOTED) {
+			if value, err := this.parseField(); err != nil {
+				return nil, err
+			} else {
+				f.value = value
+			}
+		} else if arg, err := this.parseArg(); err != nil {
+			return nil, err
+		} else {
+			f.args = append(f.args, arg)
+		}
+		if this.accept(token_PUNCT, ",") == false {
+			break
+		}
+	}
+	if err := this.expect(token_PUNCT, ")"); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// parseArg parses a literal function argument
+func (this *parser) parseArg() (string, error) {
+	tok := this.peek()
+	switch {
+	case tok.t == token_NUMBER, tok.is(token_OPERATOR, "-"):
+		if value, err := this.parseNumber(); err != nil {
+			return "", err
+		} else {
+			return literalString(value), nil
+		}
+	case tok.t == token_DURATION:
+		if duration, err := this.parseDuration(); err != nil {
+			return "", err
+		} else {
+			return durationString(duration), nil
+		}
+	case tok.t == token_STRING:
+		this.next()
+		return quoteLiteral(tok.value), nil
+	case tok.t == token_IDENT || tok.t == token_QUOTED:
+		this.next()
+		return Quote(tok.value), nil
+	default:
+		return "", this.expected("argument")
+	}
+}
+
+// parseDimension parses a tag, a wildcard or a time interval
+func (this *parser) parseDimension() (Predicate, error) {
+	tok := this.peek()
+	switch {
+	case tok.is(token_PUNCT, "*"):
+		this.next()
+		return Tag("*"), nil
+	case tok.isKeyword("TIME") && this.peekAt(1).is(token_PUNCT, "("):
+		this.next()
+		this.next()
+		interval, err := this.parseDuration()
+		if err != nil {
+			return nil, err
+		}
+		offset := time.Duration(0)
+		if this.accept(token_PUNCT, ",") {
+			negative := this.accept(token_OPERATOR, "-")
+			if offset, err = this.parseDuration(); err != nil {
+				return nil, err
+			} else if negative {
+				offset = -offset
+			}
+		}
+		if err := this.expect(token_PUNCT, ")"); err != nil {
+			return nil, err
+		}
+		return Time(interval, offset), nil
+	case tok.t == token_IDENT || tok.t == token_QUOTED:
+		this.next()
+		return Tag(tok.value), nil
+	default:
+		return nil, this.expected("tag or time interval")
+	}
+}
+
+// parseFill parses the value for the fill clause
+func (this *parser) parseFill() (Value, error) {
+	tok := this.peek()
+	if tok.t == token_NUMBER || tok.is(token_OPERATOR, "-") {
+		return this.parseNumber()
+	}
+	for _, value := range []string{FILL_NULL, FILL_NONE, FILL_PREVIOUS, FILL_LINEAR} {
+		if tok.t == token_IDENT && strings.ToLower(tok.value) == value {
+			this.next()
+			return value, nil
+		}
+	}
+	return nil, this.expected("null, none, previous, linear or number")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONDITIONS
+
+// parseCondition parses conditions joined with OR
+func (this *parser) parseCondition() (Predicate, error) {
+	values := make([]Predicate, 0, 1)
+	for {
+		if value, err := this.parseAnd(); err != nil {
+			return nil, err
+		} else if or, ok := value.(*p_Or); ok {
+			values = append(values, or.values...)
+		} else {
+			values = append(values, value)
+		}
+		if this.acceptKeyword("OR") == false {
+			break
+		}
+	}
+	if len(values) == 1 {
+		return values[0], nil
+	} else {
+		return Or(values...), nil
+	}
+}
+
+// parseAnd parses conditions joined with AND
+func (this *parser) parseAnd() (Predicate, error) {
+	values := make([]Predicate, 0, 1)
+	for {
+		if value, err := this.parseComparison(); err != nil {
+			return nil, err
+		} else if and, ok := value.(*p_And); ok {
+			values = append(values, and.values...)
+		} else {
+			values = append(values, value)
+		}
+		if this.acceptKeyword("AND") == false {
+			break
+		}
+	}
+	if len(values) == 1 {
+		return values[0], nil
+	} else {
+		return And(values...), nil
+	}
+}
+
+// parseComparison parses a condition in parentheses, or a comparison of
+// a tag, field or time with a value. Comparisons with strings and regular
+// expressions are returned as tag predicates. Single-quoted strings are
+// literals, and double-quoted strings are identifiers
+func (this *parser) parseComparison() (Predicate, error) {
+	if this.accept(token_PUNCT, "(") {
+		if value, err := this.parseCondition(); err != nil {
+			return nil, err
+		} else if err := this.expect(token_PUNCT, ")"); err != nil {
+			return nil, err
+		} else {
+			return value, nil
+		}
+	}
+
+	// Name of tag, field or time
+	tok := this.peek()
+	if tok.t != token_IDENT && tok.t != token_QUOTED {
+		return nil, this.expected("tag, field or time")
+	}
+	this.next()
+	name := tok.value
+
+	// Tag values
+	if this.acceptKeyword("IN") {
+		values := make([]Value, 0, 2)
+		if err := this.expect(token_PUNCT, "("); err != nil {
+			return nil, err
+		}
+		for {
+			if value := this.peek(); value.t == token_PARAM {
+				this.next()
+				values = append(values, Param(value.value))
+			} else if value.t == token_QUOTED {
+				this.next()
+				values = append(values, &p_Identifier{name: value.value})
+			} else if value.t != token_STRING {
+				return nil, this.expected("string")
+			} else {
+				this.next()
+				values = append(values, value.value)
+			}
+			if this.accept(token_PUNCT, ",") == false {
+				break
+			}
+		}
+		if err := this.expect(token_PUNCT, ")"); err != nil {
+			return nil, err
+		}
+		return &p_TagClause{name: name, value: values, op: "="}, nil
+	}
+
+	// Operator
+	op := this.peek()
+	if op.t != token_OPERATOR || op.value == "+" || op.value == "-" {
+		return nil, this.expected("comparison operator")
+	}
+	this.next()
+	if op.value == "<>" {
+		op.value = "!="
+	}
+
+	// Time
+	if tok.t == token_IDENT && strings.ToUpper(name) == "TIME" {
+		if op.value == "=~" || op.value == "!~" {
+			return nil, this.errorAt(op.pos, "Invalid operator %v for time", op.value)
+		} else if value, err := this.parseTime(); err != nil {
+			return nil, err
+		} else {
+			return &p_TimeClause{op: op.value, value: value}, nil
+		}
+	}
+
+	// Value
+	value := this.peek()
+	switch {
+	case op.value == "=~" || op.value == "!~":
+		if value.t != token_REGEX {
+			return nil, this.expected("regular expression")
+		}
+		this.next()
+		return &p_TagClause{name: name, value: []Value{value.value}, op: op.value}, nil
+	case value.t == token_STRING:
+		this.next()
+		if op.value == "=" || op.value == "!=" {
+			return &p_TagClause{name: name, value: []Value{value.value}, op: op.value}, nil
+		} else {
+			return &p_FieldClause{name: name, value: quoteLiteral(value.value), op: op.value}, nil
+		}
+	case value.t == token_QUOTED:
+		this.next()
+		if op.value == "=" || op.value == "!=" {
+			return &p_TagClause{name: name, value: []Value{&p_Identifier{name: value.value}}, op: op.value}, nil
+		} else {
+			return &p_FieldClause{name: name, value: QuoteString(value.value), op: op.value}, nil
+		}
+	case value.t == token_PARAM:
+		this.next()
+		if op.value == "=" || op.value == "!=" {
+			return &p_TagClause{name: name, value: []Value{Param(value.value)}, op: op.value}, nil
+		} else {
+			return &p_FieldClause{name: name, value: literalString(Param(value.value)), op: op.value}, nil
+		}
+	case value.t == token_NUMBER || value.is(token_OPERATOR, "-"):
+		if n, err := this.parseNumber(); err != nil {
+			return nil, err
+		} else {
+			return &p_FieldClause{name: name, value: literalString(n), op: op.value}, nil
+		}
+	case value.isKeyword("TRUE") || value.isKeyword("FALSE"):
+		this.next()
+		return &p_FieldClause{name: name, value: strings.ToLower(value.value), op: op.value}, nil
+	default:
+		return nil, this.expected("string, number, boolean or parameter")
+	}
+}
+
+// parseTime parses a time literal, which is a string, an integer, a
+// duration or now(), with an optional duration added or subtracted
+func (this *parser) parseTime() (string, error) {
+	tok := this.peek()
+	value := ""
+	switch {
+	case tok.t == token_STRING:
+		this.next()
+		value = quoteLiteral(tok.value)
+	case tok.t == token_PARAM:
+		this.next()
+		value = Param(tok.value).String()
+	case tok.t == token_NUMBER:
+		this.next()
+		if _, err := strconv.ParseInt(tok.value, 10, 64); err != nil {
+			return "", this.errorAt(tok.pos, "Invalid time: %v", tok.value)
+		}
+		value = tok.value
+	case tok.t == token_DURATION:
+		if duration, err := this.parseDuration(); err != nil {
+			return "", err
+		} else {
+			value = durationString(duration)
+		}
+	case tok.isKeyword("NOW") && this.peekAt(1).is(token_PUNCT, "(") && this.peekAt(2).is(token_PUNCT, ")"):
+		this.next()
+		this.next()
+		this.next()
+		value = "now()"
+	default:
+		return "", this.expected("time")
+	}
+	for {
+		if op := this.peek(); op.is(token_OPERATOR, "+") || op.is(token_OPERATOR, "-") {
+			this.next()
+			if duration, err := this.parseDuration(); err != nil {
+				return "", err
+			} else {
+				value = value + " " + op.value + " " + durationString(duration)
+			}
+		} else {
+			return value, nil
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// LITERALS
+
+func (this *parser) parseIdent(what string) (string, error) {
+	if tok := this.peek(); tok.t == token_IDENT || tok.t == token_QUOTED {
+		this.next()
+		return tok.value, nil
+	} else {
+		return "", this.expected(what)
+	}
+}
+
+func (this *parser) parseString(what string) (string, error) {
+	if tok := this.peek(); tok.t == token_STRING {
+		this.next()
+		return tok.value, nil
+	} else {
+		return "", this.expected(what)
+	}
+}
+
+func (this *parser) parseUint(what string) (uint64, error) {
+	if tok := this.peek(); tok.t != token_NUMBER {
+		return 0, this.expected(what)
+	} else if n, err := strconv.ParseUint(tok.value, 10, 64); err != nil {
+		return 0, this.errorAt(tok.pos, "Invalid %v: %v", what, tok.value)
+	} else {
+		this.next()
+		return n, nil
+	}
+}
+
+// parseNumber returns an int64 or float64 with an optional minus sign
+func (this *parser) parseNumber() (Value, error) {
+	negative := this.accept(token_OPERATOR, "-")
+	tok := this.peek()
+	if tok.t != token_NUMBER {
+		return nil, this.expected("number")
+	}
+	this.next()
+	value := tok.value
+	if negative {
+		value = "-" + value
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	} else if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f, nil
+	} else {
+		return nil, this.errorAt(tok.pos, "Invalid number: %v", tok.value)
+	}
+}
+
+func (this *parser) parseDuration() (time.Duration, error) {
+	if tok := this.peek(); tok.t != token_DURATION {
+		return 0, this.expected("duration")
+	} else if duration, ok := parseDuration(tok.value); ok == false {
+		return 0, this.errorAt(tok.pos, "Invalid duration: %v", tok.value)
+	} else {
+		this.next()
+		return duration, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TOKENS
+
+func (this *parser) peek() token {
+	return this.tokens[this.i]
+}
+
+// peekAt returns a token after the next one, or the EOF token
+func (this *parser) peekAt(n int) token {
+	if this.i+n < len(this.tokens) {
+		return this.tokens[this.i+n]
+	} else {
+		return this.tokens[len(this.tokens)-1]
+	}
+}
+
+// next consumes a token, and returns the EOF token at the end
+func (this *parser) next() token {
+	tok := this.tokens[this.i]
+	if tok.t != token_EOF {
+		this.i++
+	}
+	return tok
+}
+
+// accept consumes the next token if it matches
+func (this *parser) accept(t tokenType, value string) bool {
+	if this.peek().is(t, value) {
+		this.next()
+		return true
+	} else {
+		return false
+	}
+}
+
+func (this *parser) expect(t tokenType, value string) error {
+	if this.accept(t, value) == false {
+		return this.expected(value)
+	}
+	return nil
+}
+
+// acceptKeyword consumes the next tokens if they match all the keywords
+func (this *parser) acceptKeyword(words ...string) bool {
+	for i, word := range words {
+		if this.peekAt(i).isKeyword(word) == false {
+			return false
+		}
+	}
+	this.i += len(words)
+	return true
+}
+
+func (this *parser) expectKeyword(words ...string) error {
+	for _, word := range words {
+		if this.acceptKeyword(word) == false {
+			return this.expected(word)
+		}
+	}
+	return nil
+}
+
+// expected returns an error for the next token
+func (this *parser) expected(what string) error {
+	tok := this.peek()
+	if tok.t == token_EOF {
+		return this.errorAt(tok.pos, "found EOF, expected %v", what)
+	} else {
+		return this.errorAt(tok.pos, "found %v, expected %v", this.query[tok.pos:tok.end], what)
+	}
+}
+
+// errorAt returns an error with the line and character for a position
+func (this *parser) errorAt(pos int, format string, args ...interface{}) error {
+	line, char := 1, 1
+	for _, r := range this.query[:pos] {
+		if r == '\n' {
+			line, char = line+1, 1
+		} else {
+			char++
+		}
+	}
+	return &ParseError{fmt.Sprintf(format, args...), line, char}
+}
+
+// is returns true if the token has a type and value
+func (t token) is(tt tokenType, value string) bool {
+	return t.t == tt && t.value == value
+}
+
+// isKeyword returns true if the token is a reserved word, or a bare
+// identifier which is used as a keyword in some statements
+func (t token) isKeyword(word string) bool {
+	return (t.t == token_KEYWORD || t.t == token_IDENT) && strings.ToUpper(t.value) == word
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SCANNER
+
+func newParser(query string) (*parser, error) {
+	this := &parser{query: query}
+	for pos := 0; ; {
+		// Skip whitespace and comments
+		for pos < len(query) {
+			if r, w := utf8.DecodeRuneInString(query[pos:]); unicode.IsSpace(r) {
+				pos += w
+			} else if strings.HasPrefix(query[pos:], "--") {
+				if i := strings.IndexByte(query[pos:], '\n'); i < 0 {
+					pos = len(query)
+				} else {
+					pos += i + 1
+				}
+			} else {
+				break
+			}
+		}
+		if pos >= len(query) {
+			this.tokens = append(this.tokens, token{t: token_EOF, pos: pos, end: pos})
+			return this, nil
+		}
+		if tok, err := this.scan(pos); err != nil {
+			return nil, err
+		} else {
+			this.tokens = append(this.tokens, tok)
+			pos = tok.end
+		}
+	}
+}
+
+// scan returns the token at a position
+func (this *parser) scan(pos int) (token, error) {
+	r, w := utf8.DecodeRuneInString(this.query[pos:])
+	switch {
+	case isIdentStart(r):
+		end := this.scanWhile(pos, isIdentChar)
+		value := this.query[pos:end]
+		if isReservedWord(value) {
+			return token{token_KEYWORD, strings.ToUpper(value), pos, end}, nil
+		} else {
+			return token{token_IDENT, value, pos, end}, nil
+		}
+	case r == ':' && pos+1 < len(this.query) && isIdentStart(rune(this.query[pos+1])):
+		end := this.scanWhile(pos+1, isIdentChar)
+		return token{token_IDENT, this.query[pos:end], pos, end}, nil
+	case r == '$' && pos+1 < len(this.query) && isIdentStart(rune(this.query[pos+1])):
+		end := this.scanWhile(pos+1, isIdentChar)
+		return token{token_PARAM, this.query[pos+1 : end], pos, end}, nil
+	case r == '$' && pos+1 < len(this.query) && this.query[pos+1] == '"':
+		if tok, err := this.scanQuoted(pos+1, '"'); err != nil {
+			return token{}, err
+		} else {
+			return token{token_PARAM, tok.value, pos, tok.end}, nil
+		}
+	case r >= '0' && r <= '9':
+		end := this.scanWhile(pos, isDigit)
+		if end+1 < len(this.query) && this.query[end] == '.' && isDigit(rune(this.query[end+1])) {
+			end = this.scanWhile(end+1, isDigit)
+		}
+		if r, _ := utf8.DecodeRuneInString(this.query[end:]); unicode.IsLetter(r) {
+			end = this.scanWhile(end, func(r rune) bool { return unicode.IsLetter(r) || isDigit(r) })
+			return token{token_DURATION, this.query[pos:end], pos, end}, nil
+		}
+		return token{token_NUMBER, this.query[pos:end], pos, end}, nil
+	case r == '"' || r == '\'' || r == '/':
+		return this.scanQuoted(pos, byte(r))
+	case strings.ContainsRune("(),.*;", r):
+		return token{token_PUNCT, string(r), pos, pos + 1}, nil
+	}
+	for _, op := range []string{"=~", "!~", "!=", "<>", "<=", ">=", "=", "<", ">", "+", "-"} {
+		if strings.HasPrefix(this.query[pos:], op) {
+			return token{token_OPERATOR, op, pos, pos + len(op)}, nil
+		}
+	}
+	return token{}, this.errorAt(pos, "Unexpected character %q", this.query[pos:pos+w])
+}
+
+// scanQuoted returns an identifier, string or regular expression. Escaped
+// quotes and backslashes are unescaped in identifiers and strings, and
+// regular expressions are returned as they are
+func (this *parser) scanQuoted(pos int, quote byte) (token, error) {
+	buf := make([]byte, 0, 16)
+	for i := pos + 1; i < len(this.query); i++ {
+		switch c := this.query[i]; {
+		case c == quote:
+			switch quote {
+			case '"':
+				return token{token_QUOTED, string(buf), pos, i + 1}, nil
+			case '\'':
+				return token{token_STRING, string(buf), pos, i + 1}, nil
+			default:
+				return token{token_REGEX, string(buf), pos, i + 1}, nil
+			}
+		case c == '\\' && i+1 < len(this.query):
+			if next := this.query[i+1]; next == quote || (quote != '/' && (next == '\\' || next == '"' || next == '\'')) {
+				if quote == '/' {
+					buf = append(buf, c)
+				}
+				buf = append(buf, next)
+				i++
+			} else {
+				buf = append(buf, c)
+			}
+		case c == '\n' && quote != '\'':
+			return token{}, this.errorAt(pos, "Unterminated %q", quote)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return token{}, this.errorAt(pos, "Unterminated %q", quote)
+}
+
+func (this *parser) scanWhile(pos int, fn func(rune) bool) int {
+	for pos < len(this.query) {
+		r, w := utf8.DecodeRuneInString(this.query[pos:])
+		if fn(r) == false {
+			break
+		}
+		pos += w
+	}
+	return pos
+}
+
+func isIdentStart(r rune) bool {
+	return (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || r == '_'
+}
+
+func isIdentChar(r rune) bool {
+	return isIdentStart(r) || isDigit(r)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
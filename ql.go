@@ -10,30 +10,48 @@ package influxdb
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
 // TYPES
 
-type q_ShowDatabases struct{}
+// q_Unsupported is embedded in each statement, and provides the setters
+// which only a SELECT statement supports. These return a query which
+// fails with ErrNotSupported when it is executed
+type q_Unsupported struct{}
+
+// q_Error is a query which cannot be executed
+type q_Error struct {
+	err error
+}
+
+type q_ShowDatabases struct {
+	q_Unsupported
+}
 
 type q_CreateDatabase struct {
+	q_Unsupported
 	database   string
 	policyName string
 	policy     *RetentionPolicy
 }
 
 type q_DropDatabase struct {
+	q_Unsupported
 	database string
 }
 
 type q_DropRetentionPolicy struct {
+	q_Unsupported
 	database string
 	name     string
 }
 
 type q_AlterRetentionPolicy struct {
+	q_Unsupported
 	database string
 	name     string
 	policy   *RetentionPolicy
@@ -41,10 +59,12 @@ type q_AlterRetentionPolicy struct {
 }
 
 type q_ShowRetentionPolicies struct {
+	q_Unsupported
 	database string
 }
 
 type q_CreateRetentionPolicy struct {
+	q_Unsupported
 	database string
 	name     string
 	policy   *RetentionPolicy
@@ -52,32 +72,236 @@ type q_CreateRetentionPolicy struct {
 }
 
 type q_ShowSeries struct {
+	q_Unsupported
 	database    string
 	measurement *Measurement
+	where       []Predicate
 	limit       uint
 	offset      uint
 }
 
 type q_ShowMeasurements struct {
+	q_Unsupported
 	database    string
 	measurement *Measurement
+	where       []Predicate
 	limit       uint
 	offset      uint
 }
 
 type q_Select struct {
 	measurement []*Measurement
+	columns     []Predicate
+	into        *Measurement
+	where       []Predicate
+	groupby     []Predicate
+	fill        string
+	tz          string
+	descending  bool
+	limit       uint
+	offset      uint
+	slimit      uint
+	soffset     uint
+}
+
+type q_ShowTagKeys struct {
+	q_Unsupported
+	database    string
+	measurement *Measurement
 	where       []Predicate
 	limit       uint
 	offset      uint
 }
 
+type q_ShowTagValues struct {
+	q_Unsupported
+	database    string
+	measurement *Measurement
+	keys        []string
+	where       []Predicate
+	limit       uint
+	offset      uint
+}
+
+type q_ShowFieldKeys struct {
+	q_Unsupported
+	database    string
+	measurement *Measurement
+	limit       uint
+	offset      uint
+}
+
+type q_Delete struct {
+	q_Unsupported
+	measurement *Measurement
+	where       []Predicate
+}
+
+type q_DropSeries struct {
+	q_Unsupported
+	measurement *Measurement
+	where       []Predicate
+}
+
+type q_DropMeasurement struct {
+	q_Unsupported
+	name string
+}
+
+type q_ShowStats struct {
+	q_Unsupported
+	module string
+}
+
+type q_ShowDiagnostics struct {
+	q_Unsupported
+}
+
+type q_ShowShards struct {
+	q_Unsupported
+}
+
+type q_ShowShardGroups struct {
+	q_Unsupported
+}
+
+type q_DropShard struct {
+	q_Unsupported
+	id uint64
+}
+
+type q_ShowQueries struct {
+	q_Unsupported
+}
+
+type q_KillQuery struct {
+	q_Unsupported
+	id uint64
+}
+
+type q_ShowCardinality struct {
+	q_Unsupported
+	what        string
+	exact       bool
+	key         string
+	database    string
+	measurement *Measurement
+	where       []Predicate
+}
+
+type q_ShowContinuousQueries struct {
+	q_Unsupported
+}
+
+type q_CreateContinuousQuery struct {
+	q_Unsupported
+	database string
+	name     string
+	query    Query
+	every    time.Duration
+	for_     time.Duration
+}
+
+type q_DropContinuousQuery struct {
+	q_Unsupported
+	database string
+	name     string
+}
+
+type q_ShowUsers struct {
+	q_Unsupported
+}
+
+type q_CreateUser struct {
+	q_Unsupported
+	name     string
+	password string
+	admin    bool
+}
+
+type q_DropUser struct {
+	q_Unsupported
+	name string
+}
+
+type q_SetPassword struct {
+	q_Unsupported
+	name     string
+	password string
+}
+
+type q_ShowGrants struct {
+	q_Unsupported
+	name string
+}
+
+type q_Grant struct {
+	q_Unsupported
+	name      string
+	database  string
+	privilege string
+	revoke    bool
+}
+
 type p_TagClause struct {
 	name  string
-	value []string
+	value []Value
 	op    string
 }
 
+type p_Field struct {
+	name string
+}
+
+type p_Function struct {
+	name  string
+	value Predicate
+	args  []string
+}
+
+type p_Alias struct {
+	value Predicate
+	alias string
+}
+
+type p_TimeClause struct {
+	op    string
+	value string
+}
+
+type p_FieldClause struct {
+	name  string
+	value string
+	op    string
+}
+
+type p_And struct {
+	values []Predicate
+}
+
+type p_Or struct {
+	values []Predicate
+}
+
+type p_Param struct {
+	name string
+}
+
+// p_Identifier is a double-quoted identifier which is compared with
+// a tag or field
+type p_Identifier struct {
+	name string
+}
+
+type p_Tag struct {
+	name string
+}
+
+type p_Time struct {
+	interval time.Duration
+	offset   time.Duration
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // CONSTRUCT QUERIES
 
@@ -121,19 +345,421 @@ func Select(measurements ...*Measurement) Query {
 	return &q_Select{measurement: measurements}
 }
 
+func ShowTagKeys() Query {
+	return &q_ShowTagKeys{}
+}
+
+// ShowTagValues returns a query for the values of one or more tag keys
+func ShowTagValues(keys ...string) Query {
+	return &q_ShowTagValues{keys: keys}
+}
+
+func ShowFieldKeys() Query {
+	return &q_ShowFieldKeys{}
+}
+
+// Delete returns a query which deletes points from a measurement
+// which match the filter
+func Delete(measurement *Measurement) Query {
+	return &q_Delete{measurement: measurement}
+}
+
+// DropSeries returns a query which deletes series from a measurement
+// which match the filter
+func DropSeries(measurement *Measurement) Query {
+	return &q_DropSeries{measurement: measurement}
+}
+
+func DropMeasurement(name string) Query {
+	return &q_DropMeasurement{name: name}
+}
+
+func ShowStats(module string) Query {
+	return &q_ShowStats{module: module}
+}
+
+func ShowDiagnostics() Query {
+	return &q_ShowDiagnostics{}
+}
+
+func ShowShards() Query {
+	return &q_ShowShards{}
+}
+
+func ShowShardGroups() Query {
+	return &q_ShowShardGroups{}
+}
+
+func DropShard(id uint64) Query {
+	return &q_DropShard{id: id}
+}
+
+func ShowQueries() Query {
+	return &q_ShowQueries{}
+}
+
+func KillQuery(id uint64) Query {
+	return &q_KillQuery{id: id}
+}
+
+// ShowSeriesCardinality returns a query for the number of series, which
+// is estimated unless exact is true
+func ShowSeriesCardinality(exact bool) Query {
+	return &q_ShowCardinality{what: "SERIES", exact: exact}
+}
+
+// ShowMeasurementCardinality returns a query for the number of
+// measurements, which is estimated unless exact is true
+func ShowMeasurementCardinality(exact bool) Query {
+	return &q_ShowCardinality{what: "MEASUREMENT", exact: exact}
+}
+
+// ShowTagKeyCardinality returns a query for the number of tag keys,
+// which is estimated unless exact is true
+func ShowTagKeyCardinality(exact bool) Query {
+	return &q_ShowCardinality{what: "TAG KEY", exact: exact}
+}
+
+// ShowTagValuesCardinality returns a query for the number of values for
+// a tag key, which is estimated unless exact is true
+func ShowTagValuesCardinality(exact bool, key string) Query {
+	return &q_ShowCardinality{what: "TAG VALUES", exact: exact, key: key}
+}
+
+// ShowFieldKeyCardinality returns a query for the number of field keys,
+// which is estimated unless exact is true
+func ShowFieldKeyCardinality(exact bool) Query {
+	return &q_ShowCardinality{what: "FIELD KEY", exact: exact}
+}
+
+func ShowContinuousQueries() Query {
+	return &q_ShowContinuousQueries{}
+}
+
+// CreateContinuousQuery returns a query which creates a continuous query
+// from a Select query. When every is non-zero the query is run at that
+// interval, and when for_ is non-zero it covers that time range
+func CreateContinuousQuery(database, name string, query Query, every, for_ time.Duration) Query {
+	return &q_CreateContinuousQuery{database: database, name: name, query: query, every: every, for_: for_}
+}
+
+func DropContinuousQuery(database, name string) Query {
+	return &q_DropContinuousQuery{database: database, name: name}
+}
+
+func ShowUsers() Query {
+	return &q_ShowUsers{}
+}
+
+// CreateUser returns a query which creates a user, with all privileges
+// when admin is true
+func CreateUser(name, password string, admin bool) Query {
+	return &q_CreateUser{name: name, password: password, admin: admin}
+}
+
+func DropUser(name string) Query {
+	return &q_DropUser{name: name}
+}
+
+func SetPassword(name, password string) Query {
+	return &q_SetPassword{name: name, password: password}
+}
+
+func ShowGrants(name string) Query {
+	return &q_ShowGrants{name: name}
+}
+
+// Grant returns a query which grants a privilege on a database to a user.
+// When database is empty, PRIVILEGE_ALL grants admin privileges
+func Grant(privilege, database, name string) Query {
+	return &q_Grant{privilege: privilege, database: database, name: name}
+}
+
+// Revoke returns a query which revokes a privilege on a database from a
+// user. When database is empty, PRIVILEGE_ALL revokes admin privileges
+func Revoke(privilege, database, name string) Query {
+	return &q_Grant{privilege: privilege, database: database, name: name, revoke: true}
+}
+
+// Subquery returns a query which can be used as a source for Select
+func Subquery(query Query) *Measurement {
+	return &Measurement{Query: query}
+}
+
+// AndFilter returns a copy of a Select query with predicates added to its
+// WHERE clause, so that the query passed in is not modified. It returns a
+// query with the error ErrNotSupported for other queries
+func AndFilter(query Query, value ...Predicate) Query {
+	switch q := query.(type) {
+	case *q_Select:
+		q_ := *q
+		q_.where = make([]Predicate, 0, len(q.where)+len(value))
+		q_.where = append(append(q_.where, q.where...), value...)
+		return &q_
+	case *q_Error:
+		return q
+	default:
+		return &q_Error{ErrNotSupported}
+	}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // CONSTRUCT PREDICATES
 
 func TagEquals(name string, value ...string) Predicate {
-	return &p_TagClause{name: name, value: value, op: "="}
+	values := make([]Value, len(value))
+	for i, v := range value {
+		values[i] = v
+	}
+	return &p_TagClause{name: name, value: values, op: "="}
+}
+
+// TagEqualsParam returns a predicate which compares a tag with a
+// placeholder, which is bound when the query is executed
+func TagEqualsParam(name, param string) Predicate {
+	return &p_TagClause{name: name, value: []Value{&p_Param{name: param}}, op: "="}
 }
 
 func TagNotEquals(name, value string) Predicate {
-	return &p_TagClause{name: name, value: []string{value}, op: "!="}
+	return &p_TagClause{name: name, value: []Value{value}, op: "!="}
 }
 
 func TagMatches(name, regexp string) Predicate {
-	return &p_TagClause{name: name, value: []string{regexp}, op: "=~"}
+	return &p_TagClause{name: name, value: []Value{regexp}, op: "=~"}
+}
+
+// TimeRange returns a predicate for times from (inclusive) until to
+// (exclusive), rendered at the precision. Either time can be zero
+func TimeRange(from, to time.Time, precision string) Predicate {
+	p := &p_And{values: make([]Predicate, 0, 2)}
+	if from.IsZero() == false {
+		p.values = append(p.values, TimeAfter(from, precision))
+	}
+	if to.IsZero() == false {
+		p.values = append(p.values, TimeBefore(to, precision))
+	}
+	return p
+}
+
+// TimeAfter returns a predicate for times at or after value
+func TimeAfter(value time.Time, precision string) Predicate {
+	return &p_TimeClause{op: ">=", value: timeString(value, precision)}
+}
+
+// TimeBefore returns a predicate for times before value
+func TimeBefore(value time.Time, precision string) Predicate {
+	return &p_TimeClause{op: "<", value: timeString(value, precision)}
+}
+
+// TimeSince returns a predicate for times within a duration of now
+func TimeSince(value time.Duration) Predicate {
+	return &p_TimeClause{op: ">", value: "now() - " + durationString(value)}
+}
+
+func FieldEquals(name string, value Value) Predicate {
+	return &p_FieldClause{name: name, value: literalString(value), op: "="}
+}
+
+func FieldNotEquals(name string, value Value) Predicate {
+	return &p_FieldClause{name: name, value: literalString(value), op: "!="}
+}
+
+func FieldGreater(name string, value Value) Predicate {
+	return &p_FieldClause{name: name, value: literalString(value), op: ">"}
+}
+
+func FieldGreaterEquals(name string, value Value) Predicate {
+	return &p_FieldClause{name: name, value: literalString(value), op: ">="}
+}
+
+func FieldLess(name string, value Value) Predicate {
+	return &p_FieldClause{name: name, value: literalString(value), op: "<"}
+}
+
+func FieldLessEquals(name string, value Value) Predicate {
+	return &p_FieldClause{name: name, value: literalString(value), op: "<="}
+}
+
+// And returns a predicate which is true when all values are true
+func And(values ...Predicate) Predicate {
+	return &p_And{values: values}
+}
+
+// Or returns a predicate which is true when any value is true
+func Or(values ...Predicate) Predicate {
+	return &p_Or{values: values}
+}
+
+// Not returns the negation of a predicate. InfluxQL has no NOT operator, so
+// comparisons are negated by inverting the operator, and AND and OR are
+// negated using De Morgan's laws. It returns ErrNotSupported for any other
+// predicate
+func Not(value Predicate) (Predicate, error) {
+	switch p := value.(type) {
+	case *p_TagClause:
+		if op := invertOperator(p.op); op == "" {
+			break
+		} else if len(p.value) > 1 {
+			values := make([]Predicate, len(p.value))
+			for i, v := range p.value {
+				values[i] = &p_TagClause{name: p.name, value: []Value{v}, op: op}
+			}
+			return &p_And{values: values}, nil
+		} else {
+			return &p_TagClause{name: p.name, value: p.value, op: op}, nil
+		}
+	case *p_FieldClause:
+		if op := invertOperator(p.op); op != "" {
+			return &p_FieldClause{name: p.name, value: p.value, op: op}, nil
+		}
+	case *p_TimeClause:
+		if op := invertOperator(p.op); op != "" {
+			return &p_TimeClause{value: p.value, op: op}, nil
+		}
+	case *p_And:
+		if values, err := notPredicates(p.values); err != nil {
+			return nil, err
+		} else {
+			return &p_Or{values: values}, nil
+		}
+	case *p_Or:
+		if values, err := notPredicates(p.values); err != nil {
+			return nil, err
+		} else {
+			return &p_And{values: values}, nil
+		}
+	}
+	return nil, ErrNotSupported
+}
+
+// Param returns a placeholder for a value which is bound when the
+// query is executed, so that the value does not need to be quoted
+func Param(name string) Predicate {
+	return &p_Param{name: name}
+}
+
+func Field(name string) Predicate {
+	return &p_Field{name: name}
+}
+
+func As(value Predicate, alias string) Predicate {
+	return &p_Alias{value: value, alias: alias}
+}
+
+// Tag returns a tag for grouping, or all tags when name is "*"
+func Tag(name string) Predicate {
+	return &p_Tag{name: name}
+}
+
+// Time returns a time interval for grouping, with an optional
+// offset which shifts the interval boundaries
+func Time(interval, offset time.Duration) Predicate {
+	return &p_Time{interval: interval, offset: offset}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// CONSTRUCT AGGREGATE FUNCTIONS
+
+func Count(value Predicate) Predicate {
+	return &p_Function{name: "COUNT", value: value}
+}
+
+func Distinct(value Predicate) Predicate {
+	return &p_Function{name: "DISTINCT", value: value}
+}
+
+func Integral(value Predicate, unit time.Duration) Predicate {
+	return &p_Function{name: "INTEGRAL", value: value, args: durationArgs(unit)}
+}
+
+func Mean(value Predicate) Predicate {
+	return &p_Function{name: "MEAN", value: value}
+}
+
+func Median(value Predicate) Predicate {
+	return &p_Function{name: "MEDIAN", value: value}
+}
+
+func Mode(value Predicate) Predicate {
+	return &p_Function{name: "MODE", value: value}
+}
+
+func Spread(value Predicate) Predicate {
+	return &p_Function{name: "SPREAD", value: value}
+}
+
+func Stddev(value Predicate) Predicate {
+	return &p_Function{name: "STDDEV", value: value}
+}
+
+func Sum(value Predicate) Predicate {
+	return &p_Function{name: "SUM", value: value}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// CONSTRUCT SELECTOR FUNCTIONS
+
+func Bottom(value Predicate, n uint) Predicate {
+	return &p_Function{name: "BOTTOM", value: value, args: []string{fmt.Sprint(n)}}
+}
+
+func First(value Predicate) Predicate {
+	return &p_Function{name: "FIRST", value: value}
+}
+
+func Last(value Predicate) Predicate {
+	return &p_Function{name: "LAST", value: value}
+}
+
+func Max(value Predicate) Predicate {
+	return &p_Function{name: "MAX", value: value}
+}
+
+func Min(value Predicate) Predicate {
+	return &p_Function{name: "MIN", value: value}
+}
+
+func Percentile(value Predicate, n float64) Predicate {
+	return &p_Function{name: "PERCENTILE", value: value, args: []string{strconv.FormatFloat(n, 'f', -1, 64)}}
+}
+
+func Sample(value Predicate, n uint) Predicate {
+	return &p_Function{name: "SAMPLE", value: value, args: []string{fmt.Sprint(n)}}
+}
+
+func Top(value Predicate, n uint) Predicate {
+	return &p_Function{name: "TOP", value: value, args: []string{fmt.Sprint(n)}}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// CONSTRUCT TRANSFORMATION FUNCTIONS
+
+func CumulativeSum(value Predicate) Predicate {
+	return &p_Function{name: "CUMULATIVE_SUM", value: value}
+}
+
+func Derivative(value Predicate, unit time.Duration) Predicate {
+	return &p_Function{name: "DERIVATIVE", value: value, args: durationArgs(unit)}
+}
+
+func Difference(value Predicate) Predicate {
+	return &p_Function{name: "DIFFERENCE", value: value}
+}
+
+func Elapsed(value Predicate, unit time.Duration) Predicate {
+	return &p_Function{name: "ELAPSED", value: value, args: durationArgs(unit)}
+}
+
+func MovingAverage(value Predicate, n uint) Predicate {
+	return &p_Function{name: "MOVING_AVERAGE", value: value, args: []string{fmt.Sprint(n)}}
+}
+
+func NonNegativeDerivative(value Predicate, unit time.Duration) Predicate {
+	return &p_Function{name: "NON_NEGATIVE_DERIVATIVE", value: value, args: durationArgs(unit)}
+}
+
+func NonNegativeDifference(value Predicate) Predicate {
+	return &p_Function{name: "NON_NEGATIVE_DIFFERENCE", value: value}
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -148,7 +774,63 @@ func (q *q_ShowRetentionPolicies) Database(value string) Query { q.database = va
 func (q *q_CreateRetentionPolicy) Database(value string) Query { q.database = value; return q }
 func (q *q_DropRetentionPolicy) Database(value string) Query   { q.database = value; return q }
 func (q *q_AlterRetentionPolicy) Database(value string) Query  { q.database = value; return q }
-func (q *q_Select) Database(value string) Query                { return q }
+func (q *q_ShowContinuousQueries) Database(value string) Query { return q }
+func (q *q_CreateContinuousQuery) Database(value string) Query { q.database = value; return q }
+func (q *q_DropContinuousQuery) Database(value string) Query   { q.database = value; return q }
+func (q *q_ShowUsers) Database(value string) Query             { return q }
+func (q *q_CreateUser) Database(value string) Query            { return q }
+func (q *q_DropUser) Database(value string) Query              { return q }
+func (q *q_SetPassword) Database(value string) Query           { return q }
+func (q *q_ShowGrants) Database(value string) Query            { return q }
+func (q *q_Grant) Database(value string) Query                 { q.database = value; return q }
+func (q *q_ShowTagKeys) Database(value string) Query {
+	q.database = value
+	return q
+}
+func (q *q_ShowTagValues) Database(value string) Query {
+	q.database = value
+	return q
+}
+func (q *q_ShowFieldKeys) Database(value string) Query {
+	q.database = value
+	return q
+}
+func (q *q_ShowCardinality) Database(value string) Query {
+	q.database = value
+	return q
+}
+func (q *q_Delete) Database(value string) Query          { return q }
+func (q *q_DropSeries) Database(value string) Query      { return q }
+func (q *q_DropMeasurement) Database(value string) Query { return q }
+func (q *q_ShowQueries) Database(value string) Query     { return q }
+func (q *q_KillQuery) Database(value string) Query       { return q }
+func (q *q_ShowShards) Database(value string) Query      { return q }
+func (q *q_ShowShardGroups) Database(value string) Query { return q }
+func (q *q_DropShard) Database(value string) Query       { return q }
+func (q *q_ShowStats) Database(value string) Query       { return q }
+func (q *q_ShowDiagnostics) Database(value string) Query { return q }
+func (q *q_Select) Database(value string) Query {
+	// Set the database for measurements which do not have one, and for
+	// copies of subqueries
+	measurements := make([]*Measurement, len(q.measurement))
+	for i, m := range q.measurement {
+		if m != nil && m.Query != nil {
+			if subquery, ok := m.Query.(*q_Select); ok {
+				subquery_ := *subquery
+				m_ := *m
+				m_.Query = subquery_.Database(value)
+				m = &m_
+			}
+		} else if m != nil && m.Database == "" {
+			m_ := *m
+			m_.Database = value
+			m = &m_
+		}
+		measurements[i] = m
+	}
+	q.measurement = measurements
+	return q
+}
 
 ///////////////////////////////////////////////////////////////////////////////
 // SET RETENTION POLICY
@@ -168,7 +850,30 @@ func (q *q_AlterRetentionPolicy) RetentionPolicy(value *RetentionPolicy) Query {
 	q.policy = value
 	return q
 }
-func (q *q_Select) RetentionPolicy(value *RetentionPolicy) Query { return q }
+func (q *q_ShowContinuousQueries) RetentionPolicy(value *RetentionPolicy) Query { return q }
+func (q *q_CreateContinuousQuery) RetentionPolicy(value *RetentionPolicy) Query { return q }
+func (q *q_DropContinuousQuery) RetentionPolicy(value *RetentionPolicy) Query   { return q }
+func (q *q_ShowUsers) RetentionPolicy(value *RetentionPolicy) Query             { return q }
+func (q *q_CreateUser) RetentionPolicy(value *RetentionPolicy) Query            { return q }
+func (q *q_DropUser) RetentionPolicy(value *RetentionPolicy) Query              { return q }
+func (q *q_SetPassword) RetentionPolicy(value *RetentionPolicy) Query           { return q }
+func (q *q_ShowGrants) RetentionPolicy(value *RetentionPolicy) Query            { return q }
+func (q *q_Grant) RetentionPolicy(value *RetentionPolicy) Query                 { return q }
+func (q *q_ShowTagKeys) RetentionPolicy(value *RetentionPolicy) Query           { return q }
+func (q *q_ShowTagValues) RetentionPolicy(value *RetentionPolicy) Query         { return q }
+func (q *q_ShowFieldKeys) RetentionPolicy(value *RetentionPolicy) Query         { return q }
+func (q *q_ShowCardinality) RetentionPolicy(value *RetentionPolicy) Query       { return q }
+func (q *q_Delete) RetentionPolicy(value *RetentionPolicy) Query                { return q }
+func (q *q_DropSeries) RetentionPolicy(value *RetentionPolicy) Query            { return q }
+func (q *q_DropMeasurement) RetentionPolicy(value *RetentionPolicy) Query       { return q }
+func (q *q_ShowQueries) RetentionPolicy(value *RetentionPolicy) Query           { return q }
+func (q *q_KillQuery) RetentionPolicy(value *RetentionPolicy) Query             { return q }
+func (q *q_ShowShards) RetentionPolicy(value *RetentionPolicy) Query            { return q }
+func (q *q_ShowShardGroups) RetentionPolicy(value *RetentionPolicy) Query       { return q }
+func (q *q_DropShard) RetentionPolicy(value *RetentionPolicy) Query             { return q }
+func (q *q_ShowStats) RetentionPolicy(value *RetentionPolicy) Query             { return q }
+func (q *q_ShowDiagnostics) RetentionPolicy(value *RetentionPolicy) Query       { return q }
+func (q *q_Select) RetentionPolicy(value *RetentionPolicy) Query                { return q }
 
 ///////////////////////////////////////////////////////////////////////////////
 // SET DEFAULT
@@ -182,6 +887,29 @@ func (q *q_ShowRetentionPolicies) Default(value bool) Query { return q }
 func (q *q_CreateRetentionPolicy) Default(value bool) Query { q.defalt = true; return q }
 func (q *q_DropRetentionPolicy) Default(value bool) Query   { return q }
 func (q *q_AlterRetentionPolicy) Default(value bool) Query  { q.defalt = true; return q }
+func (q *q_ShowContinuousQueries) Default(value bool) Query { return q }
+func (q *q_CreateContinuousQuery) Default(value bool) Query { return q }
+func (q *q_DropContinuousQuery) Default(value bool) Query   { return q }
+func (q *q_ShowUsers) Default(value bool) Query             { return q }
+func (q *q_CreateUser) Default(value bool) Query            { return q }
+func (q *q_DropUser) Default(value bool) Query              { return q }
+func (q *q_SetPassword) Default(value bool) Query           { return q }
+func (q *q_ShowGrants) Default(value bool) Query            { return q }
+func (q *q_Grant) Default(value bool) Query                 { return q }
+func (q *q_ShowTagKeys) Default(value bool) Query           { return q }
+func (q *q_ShowTagValues) Default(value bool) Query         { return q }
+func (q *q_ShowFieldKeys) Default(value bool) Query         { return q }
+func (q *q_ShowCardinality) Default(value bool) Query       { return q }
+func (q *q_Delete) Default(value bool) Query                { return q }
+func (q *q_DropSeries) Default(value bool) Query            { return q }
+func (q *q_DropMeasurement) Default(value bool) Query       { return q }
+func (q *q_ShowQueries) Default(value bool) Query           { return q }
+func (q *q_KillQuery) Default(value bool) Query             { return q }
+func (q *q_ShowShards) Default(value bool) Query            { return q }
+func (q *q_ShowShardGroups) Default(value bool) Query       { return q }
+func (q *q_DropShard) Default(value bool) Query             { return q }
+func (q *q_ShowStats) Default(value bool) Query             { return q }
+func (q *q_ShowDiagnostics) Default(value bool) Query       { return q }
 func (q *q_Select) Default(value bool) Query                { return q }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -204,6 +932,41 @@ func (q *q_ShowMeasurements) OffsetLimit(offset uint, limit uint) Query {
 func (q *q_CreateRetentionPolicy) OffsetLimit(offset uint, limit uint) Query { return q }
 func (q *q_DropRetentionPolicy) OffsetLimit(offset uint, limit uint) Query   { return q }
 func (q *q_AlterRetentionPolicy) OffsetLimit(offset uint, limit uint) Query  { return q }
+func (q *q_ShowContinuousQueries) OffsetLimit(offset uint, limit uint) Query { return q }
+func (q *q_CreateContinuousQuery) OffsetLimit(offset uint, limit uint) Query { return q }
+func (q *q_DropContinuousQuery) OffsetLimit(offset uint, limit uint) Query   { return q }
+func (q *q_ShowUsers) OffsetLimit(offset uint, limit uint) Query             { return q }
+func (q *q_CreateUser) OffsetLimit(offset uint, limit uint) Query            { return q }
+func (q *q_DropUser) OffsetLimit(offset uint, limit uint) Query              { return q }
+func (q *q_SetPassword) OffsetLimit(offset uint, limit uint) Query           { return q }
+func (q *q_ShowGrants) OffsetLimit(offset uint, limit uint) Query            { return q }
+func (q *q_Grant) OffsetLimit(offset uint, limit uint) Query                 { return q }
+func (q *q_ShowTagKeys) OffsetLimit(offset uint, limit uint) Query {
+	q.offset = offset
+	q.limit = limit
+	return q
+}
+func (q *q_ShowTagValues) OffsetLimit(offset uint, limit uint) Query {
+	q.offset = offset
+	q.limit = limit
+	return q
+}
+func (q *q_ShowFieldKeys) OffsetLimit(offset uint, limit uint) Query {
+	q.offset = offset
+	q.limit = limit
+	return q
+}
+func (q *q_ShowCardinality) OffsetLimit(offset uint, limit uint) Query { return q }
+func (q *q_Delete) OffsetLimit(offset uint, limit uint) Query          { return q }
+func (q *q_DropSeries) OffsetLimit(offset uint, limit uint) Query      { return q }
+func (q *q_DropMeasurement) OffsetLimit(offset uint, limit uint) Query { return q }
+func (q *q_ShowQueries) OffsetLimit(offset uint, limit uint) Query     { return q }
+func (q *q_KillQuery) OffsetLimit(offset uint, limit uint) Query       { return q }
+func (q *q_ShowShards) OffsetLimit(offset uint, limit uint) Query      { return q }
+func (q *q_ShowShardGroups) OffsetLimit(offset uint, limit uint) Query { return q }
+func (q *q_DropShard) OffsetLimit(offset uint, limit uint) Query       { return q }
+func (q *q_ShowStats) OffsetLimit(offset uint, limit uint) Query       { return q }
+func (q *q_ShowDiagnostics) OffsetLimit(offset uint, limit uint) Query { return q }
 func (q *q_Select) OffsetLimit(offset uint, limit uint) Query {
 	q.offset = offset
 	q.limit = limit
@@ -228,6 +991,71 @@ func (q *q_ShowSeries) Measurement(value ...*Measurement) Query {
 	}
 	return q
 }
+func (q *q_ShowContinuousQueries) Measurement(value ...*Measurement) Query { return q }
+func (q *q_CreateContinuousQuery) Measurement(value ...*Measurement) Query { return q }
+func (q *q_DropContinuousQuery) Measurement(value ...*Measurement) Query   { return q }
+func (q *q_ShowUsers) Measurement(value ...*Measurement) Query             { return q }
+func (q *q_CreateUser) Measurement(value ...*Measurement) Query            { return q }
+func (q *q_DropUser) Measurement(value ...*Measurement) Query              { return q }
+func (q *q_SetPassword) Measurement(value ...*Measurement) Query           { return q }
+func (q *q_ShowGrants) Measurement(value ...*Measurement) Query            { return q }
+func (q *q_Grant) Measurement(value ...*Measurement) Query                 { return q }
+func (q *q_ShowTagKeys) Measurement(value ...*Measurement) Query {
+	if len(value) > 0 {
+		q.measurement = value[0]
+	} else {
+		q.measurement = nil
+	}
+	return q
+}
+func (q *q_ShowTagValues) Measurement(value ...*Measurement) Query {
+	if len(value) > 0 {
+		q.measurement = value[0]
+	} else {
+		q.measurement = nil
+	}
+	return q
+}
+func (q *q_ShowFieldKeys) Measurement(value ...*Measurement) Query {
+	if len(value) > 0 {
+		q.measurement = value[0]
+	} else {
+		q.measurement = nil
+	}
+	return q
+}
+func (q *q_ShowCardinality) Measurement(value ...*Measurement) Query {
+	if len(value) > 0 {
+		q.measurement = value[0]
+	} else {
+		q.measurement = nil
+	}
+	return q
+}
+func (q *q_Delete) Measurement(value ...*Measurement) Query {
+	if len(value) > 0 {
+		q.measurement = value[0]
+	} else {
+		q.measurement = nil
+	}
+	return q
+}
+func (q *q_DropSeries) Measurement(value ...*Measurement) Query {
+	if len(value) > 0 {
+		q.measurement = value[0]
+	} else {
+		q.measurement = nil
+	}
+	return q
+}
+func (q *q_DropMeasurement) Measurement(value ...*Measurement) Query { return q }
+func (q *q_ShowQueries) Measurement(value ...*Measurement) Query     { return q }
+func (q *q_KillQuery) Measurement(value ...*Measurement) Query       { return q }
+func (q *q_ShowShards) Measurement(value ...*Measurement) Query      { return q }
+func (q *q_ShowShardGroups) Measurement(value ...*Measurement) Query { return q }
+func (q *q_DropShard) Measurement(value ...*Measurement) Query       { return q }
+func (q *q_ShowStats) Measurement(value ...*Measurement) Query       { return q }
+func (q *q_ShowDiagnostics) Measurement(value ...*Measurement) Query { return q }
 func (q *q_Select) Measurement(value ...*Measurement) Query {
 	q.measurement = value
 	return q
@@ -242,25 +1070,202 @@ func (q *q_ShowMeasurements) Measurement(value ...*Measurement) Query {
 }
 
 ///////////////////////////////////////////////////////////////////////////////
-// FILTER
+// FILTER
+
+func (q *q_CreateDatabase) Filter(value ...Predicate) Query        { return q }
+func (q *q_DropDatabase) Filter(value ...Predicate) Query          { return q }
+func (q *q_ShowDatabases) Filter(value ...Predicate) Query         { return q }
+func (q *q_ShowRetentionPolicies) Filter(value ...Predicate) Query { return q }
+func (q *q_CreateRetentionPolicy) Filter(value ...Predicate) Query { return q }
+func (q *q_AlterRetentionPolicy) Filter(value ...Predicate) Query  { return q }
+func (q *q_DropRetentionPolicy) Filter(value ...Predicate) Query   { return q }
+func (q *q_ShowSeries) Filter(value ...Predicate) Query {
+	q.where = value
+	return q
+}
+func (q *q_ShowMeasurements) Filter(value ...Predicate) Query {
+	q.where = value
+	return q
+}
+func (q *q_ShowContinuousQueries) Filter(value ...Predicate) Query { return q }
+func (q *q_CreateContinuousQuery) Filter(value ...Predicate) Query { return q }
+func (q *q_DropContinuousQuery) Filter(value ...Predicate) Query   { return q }
+func (q *q_ShowUsers) Filter(value ...Predicate) Query             { return q }
+func (q *q_CreateUser) Filter(value ...Predicate) Query            { return q }
+func (q *q_DropUser) Filter(value ...Predicate) Query              { return q }
+func (q *q_SetPassword) Filter(value ...Predicate) Query           { return q }
+func (q *q_ShowGrants) Filter(value ...Predicate) Query            { return q }
+func (q *q_Grant) Filter(value ...Predicate) Query                 { return q }
+func (q *q_ShowTagKeys) Filter(value ...Predicate) Query {
+	q.where = value
+	return q
+}
+func (q *q_ShowTagValues) Filter(value ...Predicate) Query {
+	q.where = value
+	return q
+}
+func (q *q_ShowCardinality) Filter(value ...Predicate) Query {
+	q.where = value
+	return q
+}
+func (q *q_Delete) Filter(value ...Predicate) Query {
+	q.where = value
+	return q
+}
+func (q *q_DropSeries) Filter(value ...Predicate) Query {
+	q.where = value
+	return q
+}
+func (q *q_DropMeasurement) Filter(value ...Predicate) Query { return q }
+func (q *q_ShowQueries) Filter(value ...Predicate) Query     { return q }
+func (q *q_KillQuery) Filter(value ...Predicate) Query       { return q }
+func (q *q_ShowShards) Filter(value ...Predicate) Query      { return q }
+func (q *q_ShowShardGroups) Filter(value ...Predicate) Query { return q }
+func (q *q_DropShard) Filter(value ...Predicate) Query       { return q }
+func (q *q_ShowStats) Filter(value ...Predicate) Query       { return q }
+func (q *q_ShowDiagnostics) Filter(value ...Predicate) Query { return q }
+func (q *q_Select) Filter(value ...Predicate) Query {
+	q.where = value
+	return q
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// COLUMNS
+
+func (q *q_Select) Columns(value ...Predicate) Query {
+	q.columns = value
+	return q
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GROUP BY
+
+func (q *q_Select) GroupBy(value ...Predicate) Query {
+	q.groupby = value
+	return q
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// FILL
+
+// Fill returns a query with the error ErrBadParameter for a value which
+// is not nil, one of the FILL_ constants or a number
+func (q *q_Select) Fill(value Value) Query {
+	switch v := value.(type) {
+	case nil:
+		q.fill = ""
+	case string:
+		switch v {
+		case FILL_NULL, FILL_NONE, FILL_PREVIOUS, FILL_LINEAR:
+			q.fill = v
+		default:
+			return &q_Error{ErrBadParameter}
+		}
+	case float32:
+		q.fill = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		q.fill = strconv.FormatFloat(v, 'f', -1, 64)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		q.fill = fmt.Sprint(v)
+	default:
+		return &q_Error{ErrBadParameter}
+	}
+	return q
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TIMEZONE
+
+func (q *q_Select) TZ(value string) Query {
+	q.tz = value
+	return q
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// ORDER
+
+func (q *q_Select) Descending(value bool) Query {
+	q.descending = value
+	return q
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// SET SERIES OFFSET AND LIMIT
+
+func (q *q_Select) SeriesOffsetLimit(offset uint, limit uint) Query {
+	q.soffset = offset
+	q.slimit = limit
+	return q
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// INTO
+
+func (q *q_Select) Into(value *Measurement) Query {
+	q.into = value
+	return q
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// UNSUPPORTED PARAMETERS
 
-func (q *q_CreateDatabase) Filter(value ...Predicate) Query        { return q }
-func (q *q_DropDatabase) Filter(value ...Predicate) Query          { return q }
-func (q *q_ShowDatabases) Filter(value ...Predicate) Query         { return q }
-func (q *q_ShowRetentionPolicies) Filter(value ...Predicate) Query { return q }
-func (q *q_CreateRetentionPolicy) Filter(value ...Predicate) Query { return q }
-func (q *q_AlterRetentionPolicy) Filter(value ...Predicate) Query  { return q }
-func (q *q_DropRetentionPolicy) Filter(value ...Predicate) Query   { return q }
-func (q *q_ShowSeries) Filter(value ...Predicate) Query            { return q }
-func (q *q_ShowMeasurements) Filter(value ...Predicate) Query      { return q }
-func (q *q_Select) Filter(value ...Predicate) Query {
-	q.where = value
-	return q
+func (q q_Unsupported) Columns(value ...Predicate) Query { return &q_Error{ErrNotSupported} }
+func (q q_Unsupported) GroupBy(value ...Predicate) Query { return &q_Error{ErrNotSupported} }
+func (q q_Unsupported) Fill(value Value) Query           { return &q_Error{ErrNotSupported} }
+func (q q_Unsupported) TZ(value string) Query            { return &q_Error{ErrNotSupported} }
+func (q q_Unsupported) Descending(value bool) Query      { return &q_Error{ErrNotSupported} }
+func (q q_Unsupported) SeriesOffsetLimit(offset uint, limit uint) Query {
+	return &q_Error{ErrNotSupported}
+}
+func (q q_Unsupported) Into(value *Measurement) Query   { return &q_Error{ErrNotSupported} }
+func (q q_Unsupported) Filter(value ...Predicate) Query { return &q_Error{ErrNotSupported} }
+
+func (q *q_Error) Database(value string) Query                     { return q }
+func (q *q_Error) RetentionPolicy(value *RetentionPolicy) Query    { return q }
+func (q *q_Error) Default(value bool) Query                        { return q }
+func (q *q_Error) Measurement(value ...*Measurement) Query         { return q }
+func (q *q_Error) OffsetLimit(offset uint, limit uint) Query       { return q }
+func (q *q_Error) Filter(value ...Predicate) Query                 { return q }
+func (q *q_Error) Columns(value ...Predicate) Query                { return q }
+func (q *q_Error) GroupBy(value ...Predicate) Query                { return q }
+func (q *q_Error) Fill(value Value) Query                          { return q }
+func (q *q_Error) TZ(value string) Query                           { return q }
+func (q *q_Error) Descending(value bool) Query                     { return q }
+func (q *q_Error) SeriesOffsetLimit(offset uint, limit uint) Query { return q }
+func (q *q_Error) Into(value *Measurement) Query                   { return q }
+
+///////////////////////////////////////////////////////////////////////////////
+// ERRORS
+
+func (q q_Unsupported) Err() error { return nil }
+func (q *q_Error) Err() error      { return q.err }
+
+// Err returns an error if a subquery cannot be executed
+func (q *q_Select) Err() error {
+	for _, m := range q.measurement {
+		if m != nil && m.Query != nil {
+			if err := m.Query.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (q *q_CreateContinuousQuery) Err() error {
+	if q.query == nil {
+		return nil
+	}
+	return q.query.Err()
 }
 
 ///////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
+func (q *q_Error) String() string {
+	return ""
+}
+
 func (p *RetentionPolicy) query(name string, create bool) string {
 	if p.Duration == 0 && p.ReplicationFactor == 0 && p.ShardGroupDuration == 0 {
 		return ""
@@ -290,22 +1295,101 @@ func (p *p_TagClause) String() string {
 		if len(p.value) > 1 {
 			values := make([]string, len(p.value))
 			for i, v := range p.value {
-				values[i] = QuoteString(v)
+				values[i] = tagString(v)
 			}
 			return Quote(p.name) + " IN (" + strings.Join(values, ",") + ")"
 		}
-	case "=~":
-		v := strings.Trim(p.value[0], "/")
-		return Quote(p.name) + " " + p.op + " /" + v + "/"
+	case "=~", "!~":
+		if v, ok := p.value[0].(string); ok {
+			return Quote(p.name) + " " + p.op + " " + quoteRegexp(v)
+		}
+	}
+	return Quote(p.name) + " " + p.op + " " + tagString(p.value[0])
+}
+
+func (p *p_Field) String() string {
+	if p.name == "*" {
+		return p.name
+	} else {
+		return Quote(p.name)
+	}
+}
+
+func (p *p_Function) String() string {
+	args := make([]string, 0, len(p.args)+1)
+	if p.value != nil {
+		args = append(args, p.value.String())
+	}
+	args = append(args, p.args...)
+	return p.name + "(" + strings.Join(args, ",") + ")"
+}
+
+func (p *p_Alias) String() string {
+	return p.value.String() + " AS " + Quote(p.alias)
+}
+
+func (p *p_TimeClause) String() string {
+	return "time " + p.op + " " + p.value
+}
+
+func (p *p_FieldClause) String() string {
+	return Quote(p.name) + " " + p.op + " " + p.value
+}
+
+func (p *p_And) String() string {
+	return joinPredicates(p.values, " AND ")
+}
+
+func (p *p_Or) String() string {
+	return joinPredicates(p.values, " OR ")
+}
+
+func (p *p_Identifier) String() string {
+	return QuoteString(p.name)
+}
+
+func (p *p_Param) String() string {
+	if isBareIdentifier(p.name) {
+		return "$" + p.name
+	} else {
+		return "$" + QuoteString(p.name)
+	}
+}
+
+func (p *p_Tag) String() string {
+	if p.name == "*" {
+		return p.name
+	} else {
+		return Quote(p.name)
+	}
+}
+
+func (p *p_Time) String() string {
+	if p.offset == 0 {
+		return "time(" + durationString(p.interval) + ")"
+	} else if p.offset < 0 {
+		return "time(" + durationString(p.interval) + ",-" + durationString(-p.offset) + ")"
+	} else {
+		return "time(" + durationString(p.interval) + "," + durationString(p.offset) + ")"
 	}
-	return Quote(p.name) + " " + p.op + " " + QuoteString(p.value[0])
 }
 
 func (m Measurement) String() string {
-	if m.Database == "" && m.Policy == "" {
-		return Quote(m.Name)
+	if m.Query != nil {
+		return "(" + m.Query.String() + ")"
+	}
+	name := Quote(m.Name)
+	if m.Name == MEASUREMENT_BACKREFERENCE {
+		name = m.Name
+	} else if m.Regexp {
+		name = quoteRegexp(m.Name)
+	}
+	if m.Database != "" {
+		return Quote(m.Database) + "." + Quote(m.Policy) + "." + name
+	} else if m.Policy != "" {
+		return Quote(m.Policy) + "." + name
 	} else {
-		return Quote(m.Database) + "." + Quote(m.Policy) + "." + Quote(m.Name)
+		return name
 	}
 }
 
@@ -321,6 +1405,9 @@ func (q *q_ShowSeries) String() string {
 	if q.measurement != nil {
 		s = s + " FROM " + q.measurement.String()
 	}
+	if where := And(q.where...).String(); where != "" {
+		s = s + " WHERE " + where
+	}
 	if q.limit > 0 {
 		s = s + " LIMIT " + fmt.Sprint(q.limit)
 	}
@@ -336,7 +1423,14 @@ func (q *q_ShowMeasurements) String() string {
 		s = s + " ON " + Quote(q.database)
 	}
 	if q.measurement != nil {
-		s = s + " WITH MEASUREMENT " + q.measurement.String()
+		if q.measurement.Regexp {
+			s = s + " WITH MEASUREMENT =~ " + q.measurement.String()
+		} else {
+			s = s + " WITH MEASUREMENT = " + q.measurement.String()
+		}
+	}
+	if where := And(q.where...).String(); where != "" {
+		s = s + " WHERE " + where
 	}
 	if q.limit > 0 {
 		s = s + " LIMIT " + fmt.Sprint(q.limit)
@@ -413,22 +1507,261 @@ func (q *q_DropDatabase) String() string {
 	return s
 }
 
+func (q *q_ShowTagKeys) String() string {
+	s := "SHOW TAG KEYS"
+	if q.database != "" {
+		s = s + " ON " + Quote(q.database)
+	}
+	if q.measurement != nil {
+		s = s + " FROM " + q.measurement.String()
+	}
+	if where := And(q.where...).String(); where != "" {
+		s = s + " WHERE " + where
+	}
+	if q.limit > 0 {
+		s = s + " LIMIT " + fmt.Sprint(q.limit)
+	}
+	if q.offset > 0 {
+		s = s + " OFFSET " + fmt.Sprint(q.offset)
+	}
+	return s
+}
+
+func (q *q_ShowTagValues) String() string {
+	s := "SHOW TAG VALUES"
+	if q.database != "" {
+		s = s + " ON " + Quote(q.database)
+	}
+	if q.measurement != nil {
+		s = s + " FROM " + q.measurement.String()
+	}
+	if len(q.keys) == 1 {
+		s = s + " WITH KEY = " + Quote(q.keys[0])
+	} else {
+		keys := make([]string, len(q.keys))
+		for i, key := range q.keys {
+			keys[i] = Quote(key)
+		}
+		s = s + " WITH KEY IN (" + strings.Join(keys, ",") + ")"
+	}
+	if where := And(q.where...).String(); where != "" {
+		s = s + " WHERE " + where
+	}
+	if q.limit > 0 {
+		s = s + " LIMIT " + fmt.Sprint(q.limit)
+	}
+	if q.offset > 0 {
+		s = s + " OFFSET " + fmt.Sprint(q.offset)
+	}
+	return s
+}
+
+func (q *q_ShowFieldKeys) String() string {
+	s := "SHOW FIELD KEYS"
+	if q.database != "" {
+		s = s + " ON " + Quote(q.database)
+	}
+	if q.measurement != nil {
+		s = s + " FROM " + q.measurement.String()
+	}
+	if q.limit > 0 {
+		s = s + " LIMIT " + fmt.Sprint(q.limit)
+	}
+	if q.offset > 0 {
+		s = s + " OFFSET " + fmt.Sprint(q.offset)
+	}
+	return s
+}
+
+func (q *q_Delete) String() string {
+	s := "DELETE"
+	if q.measurement != nil {
+		s = s + " FROM " + q.measurement.String()
+	}
+	if where := And(q.where...).String(); where != "" {
+		s = s + " WHERE " + where
+	}
+	return s
+}
+
+func (q *q_DropSeries) String() string {
+	s := "DROP SERIES"
+	if q.measurement != nil {
+		s = s + " FROM " + q.measurement.String()
+	}
+	if where := And(q.where...).String(); where != "" {
+		s = s + " WHERE " + where
+	}
+	return s
+}
+
+func (q *q_DropMeasurement) String() string {
+	return "DROP MEASUREMENT " + Quote(q.name)
+}
+
+func (q *q_ShowStats) String() string {
+	if q.module == "" {
+		return "SHOW STATS"
+	} else {
+		return "SHOW STATS FOR " + quoteLiteral(q.module)
+	}
+}
+
+func (q *q_ShowDiagnostics) String() string {
+	return "SHOW DIAGNOSTICS"
+}
+
+func (q *q_ShowShards) String() string {
+	return "SHOW SHARDS"
+}
+
+func (q *q_ShowShardGroups) String() string {
+	return "SHOW SHARD GROUPS"
+}
+
+func (q *q_DropShard) String() string {
+	return "DROP SHARD " + fmt.Sprint(q.id)
+}
+
+func (q *q_ShowQueries) String() string {
+	return "SHOW QUERIES"
+}
+
+func (q *q_KillQuery) String() string {
+	return "KILL QUERY " + fmt.Sprint(q.id)
+}
+
+func (q *q_ShowCardinality) String() string {
+	s := "SHOW " + q.what
+	if q.exact {
+		s = s + " EXACT"
+	}
+	s = s + " CARDINALITY"
+	if q.database != "" {
+		s = s + " ON " + Quote(q.database)
+	}
+	if q.measurement != nil {
+		s = s + " FROM " + q.measurement.String()
+	}
+	if q.key != "" {
+		s = s + " WITH KEY = " + Quote(q.key)
+	}
+	if where := And(q.where...).String(); where != "" {
+		s = s + " WHERE " + where
+	}
+	return s
+}
+
+func (q *q_ShowContinuousQueries) String() string {
+	return "SHOW CONTINUOUS QUERIES"
+}
+
+func (q *q_CreateContinuousQuery) String() string {
+	s := "CREATE CONTINUOUS QUERY " + Quote(q.name)
+	if q.database != "" {
+		s = s + " ON " + Quote(q.database)
+	}
+	if q.every != 0 || q.for_ != 0 {
+		s = s + " RESAMPLE"
+		if q.every != 0 {
+			s = s + " EVERY " + durationString(q.every)
+		}
+		if q.for_ != 0 {
+			s = s + " FOR " + durationString(q.for_)
+		}
+	}
+	if q.query != nil {
+		s = s + " BEGIN " + q.query.String() + " END"
+	}
+	return s
+}
+
+func (q *q_DropContinuousQuery) String() string {
+	s := "DROP CONTINUOUS QUERY " + Quote(q.name)
+	if q.database != "" {
+		s = s + " ON " + Quote(q.database)
+	}
+	return s
+}
+
+func (q *q_ShowUsers) String() string {
+	return "SHOW USERS"
+}
+
+func (q *q_CreateUser) String() string {
+	s := "CREATE USER " + Quote(q.name) + " WITH PASSWORD " + quoteLiteral(q.password)
+	if q.admin {
+		s = s + " WITH ALL PRIVILEGES"
+	}
+	return s
+}
+
+func (q *q_DropUser) String() string {
+	return "DROP USER " + Quote(q.name)
+}
+
+func (q *q_SetPassword) String() string {
+	return "SET PASSWORD FOR " + Quote(q.name) + " = " + quoteLiteral(q.password)
+}
+
+func (q *q_ShowGrants) String() string {
+	return "SHOW GRANTS FOR " + Quote(q.name)
+}
+
+func (q *q_Grant) String() string {
+	s := "GRANT " + q.privilege
+	if q.revoke {
+		s = "REVOKE " + q.privilege
+	}
+	if q.database != "" {
+		s = s + " ON " + Quote(q.database)
+	}
+	if q.revoke {
+		s = s + " FROM " + Quote(q.name)
+	} else {
+		s = s + " TO " + Quote(q.name)
+	}
+	return s
+}
+
 func (q *q_Select) String() string {
-	s := "SELECT * FROM "
+	s := "SELECT "
+	if len(q.columns) == 0 {
+		s = s + "*"
+	}
+	for i, column := range q.columns {
+		s = s + column.String()
+		if (i + 1) < len(q.columns) {
+			s = s + ","
+		}
+	}
+	if q.into != nil {
+		s = s + " INTO " + q.into.String()
+	}
+	s = s + " FROM "
 	for i, m := range q.measurement {
 		s = s + m.String()
 		if (i + 1) < len(q.measurement) {
 			s = s + ","
 		}
 	}
-	if len(q.where) > 0 {
-		s = s + " WHERE "
-		for i, predicate := range q.where {
+	if where := And(q.where...).String(); where != "" {
+		s = s + " WHERE " + where
+	}
+	if len(q.groupby) > 0 {
+		s = s + " GROUP BY "
+		for i, predicate := range q.groupby {
 			s = s + predicate.String()
-			if (i + 1) < len(q.where) {
-				s = s + " AND "
+			if (i + 1) < len(q.groupby) {
+				s = s + ","
 			}
 		}
+		if q.fill != "" {
+			s = s + " fill(" + q.fill + ")"
+		}
+	}
+	if q.descending {
+		s = s + " ORDER BY time DESC"
 	}
 	if q.limit > 0 {
 		s = s + " LIMIT " + fmt.Sprint(q.limit)
@@ -436,5 +1769,230 @@ func (q *q_Select) String() string {
 	if q.offset > 0 {
 		s = s + " OFFSET " + fmt.Sprint(q.offset)
 	}
+	if q.slimit > 0 {
+		s = s + " SLIMIT " + fmt.Sprint(q.slimit)
+	}
+	if q.soffset > 0 {
+		s = s + " SOFFSET " + fmt.Sprint(q.soffset)
+	}
+	if q.tz != "" {
+		s = s + " tz(" + quoteLiteral(q.tz) + ")"
+	}
 	return s
 }
+
+// durationArgs returns a duration argument for a function, or no
+// arguments if the duration is zero
+func durationArgs(unit time.Duration) []string {
+	if unit == 0 {
+		return nil
+	} else {
+		return []string{durationString(unit)}
+	}
+}
+
+// durationString returns a duration literal using the largest unit
+// which represents the duration exactly, for example 90m or 1500ms
+func durationString(value time.Duration) string {
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"w", time.Hour * 24 * 7},
+		{"d", time.Hour * 24},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+		{"ms", time.Millisecond},
+		{"u", time.Microsecond},
+	}
+	for _, u := range units {
+		if value%u.unit == 0 {
+			return fmt.Sprint(int64(value/u.unit)) + u.suffix
+		}
+	}
+	return fmt.Sprint(int64(value)) + "ns"
+}
+
+// timeString returns a time literal truncated to the precision
+func timeString(value time.Time, precision string) string {
+	switch precision {
+	case PRECISION_MICRO, PRECISION_MICRO2:
+		value = value.Truncate(time.Microsecond)
+	case PRECISION_MILLI:
+		value = value.Truncate(time.Millisecond)
+	case PRECISION_SECOND:
+		value = value.Truncate(time.Second)
+	case PRECISION_MINUTE:
+		value = value.Truncate(time.Minute)
+	case PRECISION_HOUR:
+		value = value.Truncate(time.Hour)
+	case PRECISION_DAY:
+		value = value.Truncate(time.Hour * 24)
+	case PRECISION_WEEK:
+		value = value.Truncate(time.Hour * 24 * 7)
+	}
+	return quoteLiteral(value.UTC().Format(time.RFC3339Nano))
+}
+
+// joinPredicates returns predicates joined with an operator, with
+// parentheses around compound predicates
+func joinPredicates(values []Predicate, op string) string {
+	s := make([]string, 0, len(values))
+	compound := make([]bool, 0, len(values))
+	for _, value := range values {
+		value = unwrapPredicate(value)
+		if str := value.String(); str != "" {
+			s = append(s, str)
+			switch v := value.(type) {
+			case *p_And:
+				compound = append(compound, len(v.values) > 1)
+			case *p_Or:
+				compound = append(compound, len(v.values) > 1)
+			default:
+				compound = append(compound, false)
+			}
+		}
+	}
+	if len(s) > 1 {
+		for i := range s {
+			if compound[i] {
+				s[i] = "(" + s[i] + ")"
+			}
+		}
+	}
+	return strings.Join(s, op)
+}
+
+// unwrapPredicate returns the predicate within an AND or OR which has a
+// single value, so that it is parenthesised by what it contains
+func unwrapPredicate(value Predicate) Predicate {
+	for {
+		switch v := value.(type) {
+		case *p_And:
+			if len(v.values) != 1 {
+				return value
+			}
+			value = v.values[0]
+		case *p_Or:
+			if len(v.values) != 1 {
+				return value
+			}
+			value = v.values[0]
+		default:
+			return value
+		}
+	}
+}
+
+// invertOperator returns the comparison operator which negates op,
+// or an empty string
+func invertOperator(op string) string {
+	switch op {
+	case "=":
+		return "!="
+	case "!=":
+		return "="
+	case "=~":
+		return "!~"
+	case "!~":
+		return "=~"
+	case ">":
+		return "<="
+	case "<=":
+		return ">"
+	case "<":
+		return ">="
+	case ">=":
+		return "<"
+	default:
+		return ""
+	}
+}
+
+// notPredicates returns the negation of each predicate
+func notPredicates(values []Predicate) ([]Predicate, error) {
+	result := make([]Predicate, len(values))
+	for i, v := range values {
+		if p, err := Not(v); err != nil {
+			return nil, err
+		} else {
+			result[i] = p
+		}
+	}
+	return result, nil
+}
+
+// tagString returns a value as a string literal for comparison with a tag
+func tagString(value Value) string {
+	switch v := value.(type) {
+	case *p_Param:
+		return v.String()
+	case *p_Identifier:
+		return v.String()
+	case string:
+		return quoteLiteral(v)
+	default:
+		return quoteLiteral(fmt.Sprint(v))
+	}
+}
+
+// literalString returns a value as a literal for comparison with a
+// field. Strings are quoted in the same way as tag values
+func literalString(value Value) string {
+	switch v := value.(type) {
+	case *p_Param:
+		return v.String()
+	case string:
+		return quoteLiteral(v)
+	case []byte:
+		return quoteLiteral(string(v))
+	case bool:
+		return strconv.FormatBool(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	default:
+		return quoteLiteral(fmt.Sprint(v))
+	}
+}
+
+// parseDuration returns a duration from an InfluxQL duration literal
+// such as 90m or 1h30m, and false if the literal is invalid
+func parseDuration(value string) (time.Duration, bool) {
+	units := map[string]time.Duration{
+		"ns": time.Nanosecond,
+		"u":  time.Microsecond,
+		"µ":  time.Microsecond,
+		"us": time.Microsecond,
+		"µs": time.Microsecond,
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  time.Hour * 24,
+		"w":  time.Hour * 24 * 7,
+	}
+	duration := time.Duration(0)
+	for value != "" {
+		i := strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return 0, false
+		}
+		j := strings.IndexFunc(value[i:], func(r rune) bool { return r >= '0' && r <= '9' })
+		if j < 0 {
+			j = len(value) - i
+		}
+		n, err := strconv.ParseInt(value[:i], 10, 64)
+		unit, exists := units[value[i:i+j]]
+		if err != nil || exists == false {
+			return 0, false
+		}
+		duration += time.Duration(n) * unit
+		value = value[i+j:]
+	}
+	return duration, true
+}
@@ -64,6 +64,35 @@ func QuoteString(value string) string {
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// quoteLiteral returns a single-quoted string literal, as used for
+// string values, time zones and time strings. Backslashes and single
+// quotes are escaped
+func quoteLiteral(value string) string {
+	value = strings.Replace(value, "\\", "\\\\", -1)
+	value = strings.Replace(value, "'", "\\'", -1)
+	return "'" + value + "'"
+}
+
+// quoteRegexp returns a regular expression between slashes, escaping
+// any slashes which are not already escaped
+func quoteRegexp(value string) string {
+	value = strings.Trim(value, "/")
+	buf := make([]byte, 0, len(value)+2)
+	buf = append(buf, '/')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\\' && i+1 < len(value):
+			buf = append(buf, c, value[i+1])
+			i++
+		case c == '/':
+			buf = append(buf, '\\', '/')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return string(append(buf, '/'))
+}
+
 func isBareIdentifier(value string) bool {
 	return regexpBareIdentifier.MatchString(value)
 }
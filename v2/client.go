@@ -10,7 +10,11 @@ package v2
 
 import (
 	"fmt"
+	"net"
+	"regexp"
+	"strings"
 	"time"
+	"unicode"
 
 	gopi "github.com/djthorpe/gopi"
 	influxdb "github.com/djthorpe/influxdb"
@@ -31,6 +35,11 @@ type Config struct {
 	Password  string
 	Precision string
 	Timeout   time.Duration
+
+	// Optional write spool, used when the server is unreachable
+	SpoolPath    string
+	SpoolMaxSize int64
+	SpoolMaxAge  time.Duration
 }
 
 // Client defines a connection to an Influx Database
@@ -42,8 +51,19 @@ type Client struct {
 	precision string
 	client    client.Client
 	version   string
+	spool     *spool
+	stop      chan struct{}
+	done      chan struct{}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Matches password string literals in CREATE USER and SET PASSWORD
+	regexpPassword = regexp.MustCompile(`(?i)(\bPASSWORD\b[^']*)'(?:[^'\\]|\\.)*'`)
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // OPEN AND CLOSE
 
@@ -67,29 +87,49 @@ func (config Config) Open(log gopi.Logger) (gopi.Driver, error) {
 		return nil, this.log.Error("%v", err)
 	}
 
-	// Ping client to make sure it exists, get InfluxDB version
-	var t time.Duration
-	if t, this.version, err = this.client.Ping(this.config.Timeout); err != nil {
-		this.client.Close()
-		this.client = nil
-		return nil, this.log.Error("%v", err)
+	// Open spool before connecting, so that points can be written when
+	// the server is unreachable
+	if config.SpoolPath != "" {
+		if this.spool, err = openSpool(config.SpoolPath, config.SpoolMaxSize, config.SpoolMaxAge, this.log); err != nil {
+			this.Close()
+			return nil, this.log.Error("%v", err)
+		}
 	}
-	this.log.Debug("InfluxDB Version=%v Ping=%v", this.version, t)
 
-	// Set database
-	if config.Database != "" {
-		if err := this.SetDatabase(config.Database); err != nil {
-			return nil, this.log.Error("Unknown database: %v", config.Database)
+	// Ping client to make sure it exists, get InfluxDB version. When there
+	// is a spool the server does not need to be reachable, but then the
+	// database is not checked
+	var t time.Duration
+	if t, this.version, err = this.client.Ping(this.config.Timeout); err != nil {
+		if this.spool == nil {
+			this.Close()
+			return nil, this.log.Error("%v", err)
+		}
+		this.log.Warn("InfluxDB unreachable, writes will be spooled: %v", err)
+		this.database = config.Database
+	} else {
+		this.log.Debug("InfluxDB Version=%v Ping=%v", this.version, t)
+		if config.Database != "" {
+			if err := this.SetDatabase(config.Database); err != nil {
+				this.Close()
+				return nil, this.log.Error("Unknown database: %v", config.Database)
+			}
 		}
 	}
 
 	// Set precision
-	if config.Precision != "" {
-		if err := this.SetPrecision(config.Precision); err != nil {
-			return nil, err
-		} else {
-			this.SetPrecision(influxdb.PRECISION_DEFAULT)
-		}
+	if config.Precision == "" {
+		this.SetPrecision(influxdb.PRECISION_DEFAULT)
+	} else if err := this.SetPrecision(config.Precision); err != nil {
+		this.Close()
+		return nil, err
+	}
+
+	// Start replaying the spool
+	if this.spool != nil {
+		this.stop = make(chan struct{})
+		this.done = make(chan struct{})
+		go this.replay()
 	}
 
 	// Return success
@@ -99,6 +139,15 @@ func (config Config) Open(log gopi.Logger) (gopi.Driver, error) {
 // Close releases any resources associated with the client connection
 func (this *Client) Close() error {
 	this.log.Debug2("<influxdb.Client>Close")
+	if this.stop != nil {
+		close(this.stop)
+		<-this.done
+		this.stop = nil
+	}
+	if this.spool != nil {
+		this.spool.Close()
+		this.spool = nil
+	}
 	if this.client != nil {
 		if err := this.client.Close(); err != nil {
 			this.client = nil
@@ -263,39 +312,513 @@ func (this *Client) RetentionPolicies() (map[string]*influxdb.RetentionPolicy, e
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Convenience methods for continuous queries
+
+// ContinuousQueries returns the continuous queries for the current
+// database, or for all databases if the database is not set
+func (this *Client) ContinuousQueries() ([]*influxdb.ContinuousQuery, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	queries := make([]*influxdb.ContinuousQuery, 0)
+	if results, err := this.Do(influxdb.ShowContinuousQueries()); err == influxdb.ErrEmptyResponse {
+		return queries, nil
+	} else if err != nil {
+		return nil, err
+	} else {
+		for _, result := range results {
+			if this.database != "" && result.Name != this.database {
+				continue
+			}
+			if cqs, err := result.ParseContinuousQueries(); err != nil {
+				return nil, err
+			} else {
+				queries = append(queries, cqs...)
+			}
+		}
+	}
+	return queries, nil
+}
+
+func (this *Client) CreateContinuousQuery(name string, query influxdb.Query, every, for_ time.Duration) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	if this.database == "" || name == "" || query == nil {
+		return influxdb.ErrBadParameter
+	}
+	if _, err := this.Do(influxdb.CreateContinuousQuery(this.database, name, query, every, for_)); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	}
+	return nil
+}
+
+func (this *Client) DropContinuousQuery(name string) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	if this.database == "" || name == "" {
+		return influxdb.ErrBadParameter
+	}
+	if _, err := this.Do(influxdb.DropContinuousQuery(this.database, name)); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Schema exploration
+
+// Schema returns the tag keys and field keys for each measurement in
+// the current database
+func (this *Client) Schema() (influxdb.Schema, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	if this.database == "" {
+		return nil, influxdb.ErrBadParameter
+	}
+	schema := make(influxdb.Schema)
+	measurement := func(name string) *influxdb.MeasurementSchema {
+		if _, exists := schema[name]; exists == false {
+			schema[name] = &influxdb.MeasurementSchema{Tags: []string{}, Fields: map[string]string{}}
+		}
+		return schema[name]
+	}
+
+	// Field keys
+	if results, err := this.Do(influxdb.ShowFieldKeys()); err != nil && err != influxdb.ErrEmptyResponse {
+		return nil, err
+	} else {
+		for _, result := range results {
+			if fields, err := result.ParseFieldKeys(); err != nil {
+				return nil, err
+			} else {
+				measurement(result.Name).Fields = fields
+			}
+		}
+	}
+
+	// Tag keys
+	if results, err := this.Do(influxdb.ShowTagKeys()); err != nil && err != influxdb.ErrEmptyResponse {
+		return nil, err
+	} else {
+		for _, result := range results {
+			if tags, err := result.ParseTagKeys(); err != nil {
+				return nil, err
+			} else {
+				measurement(result.Name).Tags = tags
+			}
+		}
+	}
+
+	return schema, nil
+}
+
+// Stats returns a snapshot of the server statistics
+func (this *Client) Stats() (*influxdb.Stats, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	if results, err := this.Do(influxdb.ShowStats("")); err != nil {
+		return nil, err
+	} else {
+		return results.ParseStats()
+	}
+}
+
+// Diagnostics returns build, runtime and system information for the server
+func (this *Client) Diagnostics() (*influxdb.Diagnostics, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	if results, err := this.Do(influxdb.ShowDiagnostics()); err != nil {
+		return nil, err
+	} else {
+		return results.ParseDiagnostics()
+	}
+}
+
+// Shards returns the shards for all databases
+func (this *Client) Shards() ([]*influxdb.Shard, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	shards := make([]*influxdb.Shard, 0)
+	if results, err := this.Do(influxdb.ShowShards()); err == influxdb.ErrEmptyResponse {
+		return shards, nil
+	} else if err != nil {
+		return nil, err
+	} else {
+		for _, result := range results {
+			if shards_, err := result.ParseShards(); err != nil {
+				return nil, err
+			} else {
+				shards = append(shards, shards_...)
+			}
+		}
+	}
+	return shards, nil
+}
+
+// ShardGroups returns the shard groups for all databases
+func (this *Client) ShardGroups() ([]*influxdb.ShardGroup, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	if results, err := this.Do(influxdb.ShowShardGroups()); err == influxdb.ErrEmptyResponse {
+		return []*influxdb.ShardGroup{}, nil
+	} else if err != nil {
+		return nil, err
+	} else if len(results) != 1 {
+		return nil, influxdb.ErrUnexpectedResponse
+	} else {
+		return results[0].ParseShardGroups()
+	}
+}
+
+func (this *Client) DropShard(id uint64) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	if _, err := this.Do(influxdb.DropShard(id)); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	}
+	return nil
+}
+
+// Queries returns the queries which are running on the server
+func (this *Client) Queries() ([]*influxdb.QueryInfo, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	if results, err := this.Do(influxdb.ShowQueries()); err == influxdb.ErrEmptyResponse {
+		return []*influxdb.QueryInfo{}, nil
+	} else if err != nil {
+		return nil, err
+	} else if len(results) != 1 {
+		return nil, influxdb.ErrUnexpectedResponse
+	} else {
+		return results[0].ParseQueries()
+	}
+}
+
+// KillQuery stops a query which is running on the server
+func (this *Client) KillQuery(id uint64) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	if _, err := this.Do(influxdb.KillQuery(id)); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	}
+	return nil
+}
+
+// Cardinality returns the result of a cardinality query, which is the
+// sum of the counts for each measurement when the query is exact
+func (this *Client) Cardinality(query influxdb.Query) (uint64, error) {
+	if this.client == nil {
+		return 0, influxdb.ErrNotConnected
+	}
+	if results, err := this.Do(query); err == influxdb.ErrEmptyResponse {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	} else {
+		return results.ParseCardinality()
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Convenience methods for users and privileges
+
+func (this *Client) Users() ([]*influxdb.User, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	if results, err := this.Do(influxdb.ShowUsers()); err == influxdb.ErrEmptyResponse {
+		return []*influxdb.User{}, nil
+	} else if err != nil {
+		return nil, err
+	} else if len(results) != 1 {
+		return nil, influxdb.ErrUnexpectedResponse
+	} else {
+		return results[0].ParseUsers()
+	}
+}
+
+func (this *Client) CreateUser(name, password string, admin bool) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	if name == "" {
+		return influxdb.ErrBadParameter
+	}
+	if _, err := this.Do(influxdb.CreateUser(name, password, admin)); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	}
+	return nil
+}
+
+func (this *Client) DropUser(name string) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	if _, err := this.Do(influxdb.DropUser(name)); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	}
+	return nil
+}
+
+func (this *Client) SetPassword(name, password string) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	if _, err := this.Do(influxdb.SetPassword(name, password)); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	}
+	return nil
+}
+
+// Grants returns the database privileges for a user
+func (this *Client) Grants(name string) ([]*influxdb.Privilege, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	if results, err := this.Do(influxdb.ShowGrants(name)); err == influxdb.ErrEmptyResponse {
+		return []*influxdb.Privilege{}, nil
+	} else if err != nil {
+		return nil, err
+	} else if len(results) != 1 {
+		return nil, influxdb.ErrUnexpectedResponse
+	} else {
+		return results[0].ParsePrivileges()
+	}
+}
+
+// Grant a privilege on a database to a user. When database is empty,
+// PRIVILEGE_ALL makes the user an admin
+func (this *Client) Grant(name, database, privilege string) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	if err := checkPrivilege(database, privilege); err != nil {
+		return err
+	}
+	if _, err := this.Do(influxdb.Grant(privilege, database, name)); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	}
+	return nil
+}
+
+// Revoke a privilege on a database from a user. When database is empty,
+// PRIVILEGE_ALL removes admin privileges
+func (this *Client) Revoke(name, database, privilege string) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	if err := checkPrivilege(database, privilege); err != nil {
+		return err
+	}
+	if _, err := this.Do(influxdb.Revoke(privilege, database, name)); err != nil && err != influxdb.ErrEmptyResponse {
+		return err
+	}
+	return nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Execute queries and re-format results
 
 func (this *Client) Do(query influxdb.Query) (influxdb.Results, error) {
+	return this.DoWithParams(query, nil)
+}
+
+// DoWithParams executes a query with values for the Param placeholders,
+// which are sent to the server separately from the query
+func (this *Client) DoWithParams(query influxdb.Query, params map[string]interface{}) (influxdb.Results, error) {
+	if this.client == nil {
+		return nil, influxdb.ErrNotConnected
+	}
+	if err := query.Err(); err != nil {
+		return nil, err
+	}
+	response, err := this.request(query.String(), params)
+	if err != nil {
+		return nil, err
+	} else if response.Err != "" {
+		return nil, influxdb.ServerError(response.Err)
+	}
+
+	// Each statement carries its own error, so the results are returned
+	// along with the error for the first statement which failed
+	results := make(influxdb.Results, 0, len(response.Results))
+	empty := true
+	for _, result := range response.Results {
+		if len(result.Series) > 0 {
+			empty = false
+		}
+		results = append(results, statementResults(result)...)
+	}
+	if err := results.Err(); err != nil {
+		return results, err
+	} else if empty {
+		return results, influxdb.ErrEmptyResponse
+	} else {
+		return results, nil
+	}
+}
+
+// DoBatch executes several queries in one request and returns the results
+// for each query in order. When any query fails the error is a BatchError
+// with an error for each query which failed
+func (this *Client) DoBatch(queries ...influxdb.Query) ([]influxdb.Results, error) {
 	if this.client == nil {
 		return nil, influxdb.ErrNotConnected
 	}
-	// Query and sanity check the response
-	response, err := this.query(query.String())
+	if len(queries) == 0 {
+		return nil, influxdb.ErrBadParameter
+	}
+	statements := make([]string, len(queries))
+	for i, query := range queries {
+		if query == nil {
+			return nil, influxdb.ErrBadParameter
+		} else if err := query.Err(); err != nil {
+			return nil, err
+		}
+		statements[i] = query.String()
+	}
+	response, err := this.request(strings.Join(statements, ";"), nil)
 	if err != nil {
 		return nil, err
+	} else if response.Err != "" {
+		return nil, influxdb.ServerError(response.Err)
+	}
+
+	// The server returns a result for each statement, with an error for
+	// statements which were not executed after an error
+	returned := make(map[int]client.Result, len(response.Results))
+	for _, result := range response.Results {
+		returned[result.StatementId] = result
+	}
+	results := make([]influxdb.Results, len(queries))
+	errs := make(influxdb.BatchError, 0)
+	for i := range queries {
+		if result, exists := returned[i]; exists == false {
+			errs = append(errs, &influxdb.StatementError{Statement: i, Err: influxdb.ErrEmptyResponse})
+		} else {
+			results[i] = statementResults(result)
+			if err := results[i].Err(); err != nil {
+				errs = append(errs, &influxdb.StatementError{Statement: i, Err: err})
+			}
+		}
 	}
-	if len(response.Results) == 0 {
-		return nil, influxdb.ErrEmptyResponse
-	}
-	if response.Results[0].Series == nil || len(response.Results[0].Series) == 0 {
-		return nil, influxdb.ErrEmptyResponse
-	}
-	r := make([]*influxdb.Result, 0, len(response.Results))
-	for i, result := range response.Results {
-		for j, series := range result.Series {
-			table := new(influxdb.Result)
-			table.Result = i
-			table.Series = j
-			table.Name = series.Name
-			table.Tags = series.Tags
-			table.Columns = series.Columns
-			table.Values = series.Values
-			table.Partial = series.Partial
-			r = append(r, table)
+	if len(errs) > 0 {
+		return results, errs
+	}
+	return results, nil
+}
+
+// Backfill runs a SELECT INTO query for each chunk of time between from
+// and to, so that each query completes within the timeout. The filter
+// predicates and the time range for each chunk are combined with the
+// WHERE clause of the query, and the query passed in is not modified.
+// It returns the number of points written
+func (this *Client) Backfill(query influxdb.Query, from, to time.Time, chunk time.Duration, filter ...influxdb.Predicate) (uint64, error) {
+	if this.client == nil {
+		return 0, influxdb.ErrNotConnected
+	}
+	if query == nil || from.IsZero() || to.After(from) == false || chunk <= 0 {
+		return 0, influxdb.ErrBadParameter
+	}
+	if err := query.Err(); err != nil {
+		return 0, err
+	}
+
+	written := uint64(0)
+	for start := from; start.Before(to); start = start.Add(chunk) {
+		end := start.Add(chunk)
+		if end.After(to) {
+			end = to
+		}
+		this.log.Debug2("<influxdb.Backfill>{ from=%v to=%v }", start, end)
+		where := make([]influxdb.Predicate, 0, len(filter)+1)
+		where = append(where, filter...)
+		q := influxdb.AndFilter(query, append(where, influxdb.TimeRange(start, end, influxdb.PRECISION_NANO))...)
+		if results, err := this.Do(q); err == influxdb.ErrEmptyResponse {
+			continue
+		} else if err != nil {
+			return written, err
+		} else if n, err := results.ParseWritten(); err != nil {
+			return written, err
+		} else {
+			written += n
+		}
+	}
+	return written, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Write spool
+
+// SpoolStats returns the depth of the write spool, which is empty
+// if no spool is configured
+func (this *Client) SpoolStats() SpoolStats {
+	if this.spool == nil {
+		return SpoolStats{}
+	} else {
+		return this.spool.Stats()
+	}
+}
+
+// replay writes spooled batches once the server can be reached,
+// backing off exponentially while it is unreachable
+func (this *Client) replay() {
+	defer close(this.done)
+	backoff := SPOOL_BACKOFF_MIN
+	for {
+		// Wait for new records or retry after backoff
+		var retry <-chan time.Time
+		if this.spool.Len() > 0 {
+			retry = time.After(backoff)
+		}
+		select {
+		case <-this.stop:
+			return
+		case <-this.spool.notify:
+			if retry == nil {
+				continue
+			}
+			// Wait for backoff when there are already records
+			select {
+			case <-this.stop:
+				return
+			case <-retry:
+			}
+		case <-retry:
+		}
+
+		// Replay segments while the server is reachable
+		for this.spool.Len() > 0 {
+			if _, _, err := this.client.Ping(this.config.Timeout); err != nil {
+				this.log.Debug("Spool: Ping: %v (retry in %v)", err, backoff)
+				break
+			} else if n, err := this.spool.replay(this.client); err != nil {
+				this.log.Debug("Spool: Replay: %v (replayed %v, retry in %v)", err, n, backoff)
+				break
+			} else {
+				this.log.Debug("Spool: Replayed %v records", n)
+				backoff = 0
+			}
+		}
+
+		// Set backoff for the next attempt
+		if backoff == 0 || this.spool.Len() == 0 {
+			backoff = SPOOL_BACKOFF_MIN
+		} else if backoff = backoff * 2; backoff > SPOOL_BACKOFF_MAX {
+			backoff = SPOOL_BACKOFF_MAX
 		}
 	}
-	return influxdb.Results(r), nil
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -312,27 +835,111 @@ func (this *Client) String() string {
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
-// Query database and return response or error
-func (this *Client) query(query string) (*client.Response, error) {
+// request sends a query and returns the response, which may contain
+// errors for each statement
+func (this *Client) request(query string, params map[string]interface{}) (*client.Response, error) {
 	if this.database != "" {
-		this.log.Debug("<influxdb.Query>{ database=%v, q=%v }", this.database, query)
+		this.log.Debug("<influxdb.Query>{ database=%v, q=%v, params=%v }", this.database, redactPasswords(query), len(params))
 	} else {
-		this.log.Debug("<influxdb.Query>{ database=<nil>, q=%v }", query)
+		this.log.Debug("<influxdb.Query>{ database=<nil>, q=%v, params=%v }", redactPasswords(query), len(params))
 	}
+	start := time.Now()
 	response, err := this.client.Query(client.Query{
-		Command:   query,
-		Database:  this.database,
-		Precision: this.precision,
+		Command:    query,
+		Database:   this.database,
+		Precision:  this.precision,
+		Parameters: params,
 	})
 	if err != nil {
-		return nil, err
-	}
-	if response.Error() != nil {
-		return nil, response.Error()
+		if isTimeout(err) {
+			this.kill(query, time.Since(start))
+		}
+		return nil, serverError(err)
 	}
 	return response, nil
 }
 
+// statementResults returns a result for each series returned by a statement,
+// or a single result without series when the statement failed or returned
+// messages without any series
+func statementResults(result client.Result) influxdb.Results {
+	var err error
+	var messages []*influxdb.Message
+	if result.Err != "" {
+		err = influxdb.ServerError(result.Err)
+	}
+	for _, message := range result.Messages {
+		messages = append(messages, &influxdb.Message{Level: message.Level, Text: message.Text})
+	}
+	if len(result.Series) == 0 {
+		if err == nil && len(messages) == 0 {
+			return nil
+		}
+		return influxdb.Results{&influxdb.Result{Result: result.StatementId, Err: err, Messages: messages}}
+	}
+	results := make(influxdb.Results, 0, len(result.Series))
+	for i, series := range result.Series {
+		results = append(results, &influxdb.Result{
+			Result:   result.StatementId,
+			Series:   i,
+			Name:     series.Name,
+			Tags:     series.Tags,
+			Columns:  series.Columns,
+			Values:   series.Values,
+			Partial:  series.Partial,
+			Err:      err,
+			Messages: messages,
+		})
+	}
+	return results
+}
+
+// kill stops a query on the server after the client has timed out, so
+// that it does not continue to use server resources. The server does not
+// report which connection started a query, so it is found by comparing the
+// database and text of running queries, ignoring queries which have been
+// running for longer than elapsed. When several queries match they cannot
+// be told apart, and none of them are killed
+func (this *Client) kill(query string, elapsed time.Duration) {
+	response, err := this.client.Query(client.Query{Command: influxdb.ShowQueries().String()})
+	if err == nil {
+		err = response.Error()
+	}
+	if err != nil {
+		this.log.Warn("Unable to kill query: %v", err)
+		return
+	}
+	matches := make([]*influxdb.QueryInfo, 0, 1)
+	for _, result := range response.Results {
+		for _, series := range result.Series {
+			queries, err := (&influxdb.Result{Columns: series.Columns, Values: series.Values}).ParseQueries()
+			if err != nil {
+				this.log.Warn("Unable to kill query: %v", err)
+				return
+			}
+			for _, q := range queries {
+				// Durations are reported in whole units, so allow for rounding
+				if q.Database != this.database || normalizeQuery(q.Query) != normalizeQuery(query) || q.Duration > elapsed+time.Second {
+					continue
+				}
+				matches = append(matches, q)
+			}
+		}
+	}
+	if len(matches) > 1 {
+		this.log.Warn("Unable to kill query: %v queries match %v", len(matches), redactPasswords(query))
+		return
+	}
+	for _, q := range matches {
+		this.log.Debug("<influxdb.Kill>{ qid=%v q=%v }", q.Id, redactPasswords(q.Query))
+		if response, err := this.client.Query(client.Query{Command: influxdb.KillQuery(q.Id).String()}); err != nil {
+			this.log.Warn("Unable to kill query %v: %v", q.Id, err)
+		} else if err := response.Error(); err != nil {
+			this.log.Warn("Unable to kill query %v: %v", q.Id, err)
+		}
+	}
+}
+
 func (this *Client) exists_string(q influxdb.Query, series string, column string, value string) (bool, error) {
 	if response, err := this.Do(q); err != nil {
 		return false, err
@@ -347,3 +954,61 @@ func (this *Client) exists_string(q influxdb.Query, series string, column string
 		return false, nil
 	}
 }
+
+// redactPasswords removes password literals from a query so that it
+// can be logged
+func redactPasswords(query string) string {
+	return regexpPassword.ReplaceAllString(query, "${1}'[REDACTED]'")
+}
+
+// checkPrivilege returns an error if the privilege is not valid, or is
+// not valid without a database
+func checkPrivilege(database, privilege string) error {
+	switch privilege {
+	case influxdb.PRIVILEGE_READ, influxdb.PRIVILEGE_WRITE:
+		if database == "" {
+			return influxdb.ErrBadParameter
+		}
+		return nil
+	case influxdb.PRIVILEGE_ALL:
+		return nil
+	default:
+		return influxdb.ErrBadParameter
+	}
+}
+
+// isTimeout returns true if the error is a timeout
+func isTimeout(err error) bool {
+	if err_, ok := err.(interface {
+		Timeout() bool
+	}); ok {
+		return err_.Timeout()
+	}
+	return false
+}
+
+// serverError returns a typed error for an error returned by the upstream
+// client. Errors which occur before the server responds are returned as-is,
+// except for timeouts
+func serverError(err error) error {
+	if err == nil {
+		return nil
+	} else if isTimeout(err) {
+		return influxdb.ErrTimeout
+	} else if _, ok := err.(net.Error); ok {
+		return err
+	} else {
+		return influxdb.ServerError(err.Error())
+	}
+}
+
+// normalizeQuery returns a query without quotes and whitespace, and in
+// lowercase, because the server re-formats the text of queries
+func normalizeQuery(query string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '"' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, query)
+}
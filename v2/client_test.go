@@ -0,0 +1,121 @@
+package v2
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	client "github.com/influxdata/influxdb/client/v2"
+	models "github.com/influxdata/influxdb/models"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// testWritten returns a response to a SELECT INTO query
+func testWritten(n string) *client.Response {
+	return &client.Response{Results: []client.Result{
+		{Series: []models.Row{{Name: "result", Columns: []string{"time", "written"}, Values: [][]interface{}{{json.Number("0"), json.Number(n)}}}}},
+	}}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func TestBackfill_001(t *testing.T) {
+	c := &testClient{responses: []*client.Response{testWritten("10"), testWritten("5")}}
+	this := testDatasetClient(c, influxdb.PRECISION_NANO)
+
+	// The query is run for each chunk, and the number of points written
+	// is the total for all chunks
+	query := influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Into(&influxdb.Measurement{Name: "cpu_copy"})
+	filter := []influxdb.Predicate{influxdb.TagEquals("host", "pi")}
+	from := time.Unix(1500000000, 0).UTC()
+	if written, err := this.Backfill(query, from, from.Add(90*time.Minute), time.Hour, filter...); err != nil {
+		t.Fatal(err)
+	} else if written != 15 {
+		t.Errorf("Unexpected written: %v", written)
+	} else if len(c.queries) != 2 {
+		t.Errorf("Unexpected queries: %v", c.queries)
+	} else if c.queries[0] != "SELECT * INTO cpu_copy FROM cpu WHERE host = 'pi' AND (time >= '2017-07-14T02:40:00Z' AND time < '2017-07-14T03:40:00Z')" {
+		t.Errorf("Unexpected query: %v", c.queries[0])
+	} else if c.queries[1] != "SELECT * INTO cpu_copy FROM cpu WHERE host = 'pi' AND (time >= '2017-07-14T03:40:00Z' AND time < '2017-07-14T04:10:00Z')" {
+		t.Errorf("Unexpected query: %v", c.queries[1])
+	}
+
+	// The query passed in is not modified
+	if query.String() != "SELECT * INTO cpu_copy FROM cpu" {
+		t.Errorf("Unexpected query: %v", query)
+	} else if len(filter) != 1 || cap(filter) != 1 {
+		t.Errorf("Unexpected filter: %v", filter)
+	}
+
+	// The WHERE clause of the query is kept for every chunk
+	c.queries, c.responses = nil, []*client.Response{testWritten("1"), testWritten("1")}
+	query = influxdb.Select(&influxdb.Measurement{Name: "cpu"}).Columns(influxdb.Mean(influxdb.Field("value"))).Into(&influxdb.Measurement{Name: "cpu_1h"}).Filter(influxdb.Or(influxdb.TagEquals("host", "pi"), influxdb.TagEquals("host", "pc"))).GroupBy(influxdb.Time(time.Hour, 0))
+	if _, err := this.Backfill(query, from, from.Add(90*time.Minute), time.Hour, filter...); err != nil {
+		t.Fatal(err)
+	} else if len(c.queries) != 2 {
+		t.Errorf("Unexpected queries: %v", c.queries)
+	} else if c.queries[0] != "SELECT MEAN(value) INTO cpu_1h FROM cpu WHERE (host = 'pi' OR host = 'pc') AND host = 'pi' AND (time >= '2017-07-14T02:40:00Z' AND time < '2017-07-14T03:40:00Z') GROUP BY time(1h)" {
+		t.Errorf("Unexpected query: %v", c.queries[0])
+	} else if c.queries[1] != "SELECT MEAN(value) INTO cpu_1h FROM cpu WHERE (host = 'pi' OR host = 'pc') AND host = 'pi' AND (time >= '2017-07-14T03:40:00Z' AND time < '2017-07-14T04:10:00Z') GROUP BY time(1h)" {
+		t.Errorf("Unexpected query: %v", c.queries[1])
+	} else if query.String() != "SELECT MEAN(value) INTO cpu_1h FROM cpu WHERE host = 'pi' OR host = 'pc' GROUP BY time(1h)" {
+		t.Errorf("Unexpected query: %v", query)
+	}
+
+	// An unexpected value for the number of points written returns an error
+	c.responses = []*client.Response{testWritten("x")}
+	if _, err := this.Backfill(query, from, from.Add(time.Hour), time.Hour); err != influxdb.ErrUnexpectedResponse {
+		t.Errorf("Expected ErrUnexpectedResponse, got %v", err)
+	}
+}
+
+func TestKill_001(t *testing.T) {
+	queries := func(rows ...[]interface{}) *client.Response {
+		return &client.Response{Results: []client.Result{
+			{Series: []models.Row{{Columns: []string{"qid", "query", "database", "duration", "status"}, Values: rows}}},
+		}}
+	}
+	query := "SELECT * FROM cpu"
+
+	// Only the query on this database which started after the request
+	// was sent is killed
+	c := &testClient{responses: []*client.Response{queries(
+		[]interface{}{json.Number("1"), "SELECT * FROM cpu", "other", "5s", "running"},
+		[]interface{}{json.Number("2"), "SELECT * FROM \"cpu\"", "db", "5s", "running"},
+		[]interface{}{json.Number("3"), "SELECT * FROM cpu", "db", "1h", "running"},
+		[]interface{}{json.Number("4"), "SHOW QUERIES", "", "0s", "running"},
+	)}}
+	this := testDatasetClient(c, influxdb.PRECISION_NANO)
+	this.kill(query, 5*time.Second)
+	if len(c.queries) != 2 || c.queries[1] != "KILL QUERY 2" {
+		t.Errorf("Unexpected queries: %v", c.queries)
+	}
+
+	// When several queries match, none are killed
+	c = &testClient{responses: []*client.Response{queries(
+		[]interface{}{json.Number("1"), "SELECT * FROM cpu", "db", "4s", "running"},
+		[]interface{}{json.Number("2"), "SELECT * FROM cpu", "db", "5s", "running"},
+	)}}
+	this = testDatasetClient(c, influxdb.PRECISION_NANO)
+	this.kill(query, 5*time.Second)
+	if len(c.queries) != 1 {
+		t.Errorf("Unexpected queries: %v", c.queries)
+	}
+}
+
+func TestOpen_001(t *testing.T) {
+	path := testTempDir(t)
+	defer os.RemoveAll(path)
+
+	// The spool is closed when the client cannot be opened
+	config := Config{Host: "127.0.0.1", Port: 1, Timeout: time.Second, Precision: "x", SpoolPath: path}
+	if driver, err := config.Open(gopi.NullLogger()); err != influxdb.ErrBadParameter {
+		t.Errorf("Expected ErrBadParameter, got %v", err)
+	} else if driver != nil {
+		t.Errorf("Unexpected driver: %v", driver)
+	}
+}
@@ -9,10 +9,11 @@
 package v2
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
-	"github.com/djthorpe/gopi"
 	"github.com/djthorpe/influxdb"
 	v2 "github.com/influxdata/influxdb/client/v2"
 )
@@ -33,12 +34,14 @@ type dataset struct {
 ////////////////////////////////////////////////////////////////////////////////
 // CONSTRUCTOR
 
-// NewDataset returns an empty dataset object used for writing
-func (this *Client) NewDataset(name string, fields ...string) (influxdb.Dataset, error) {
+// NewDataset returns an empty dataset object used for writing, with
+// tag keys and field names. Values for tags are set with SetTag and
+// values for fields are added in the same order as the field names
+func (this *Client) NewDataset(name string, tags, fields []string) (influxdb.Dataset, error) {
 	d := new(dataset)
 
 	// Set measurement name and database name
-	if this.database == "" || name == "" {
+	if this.database == "" || name == "" || len(fields) == 0 {
 		return nil, influxdb.ErrBadParameter
 	} else {
 		d.database = this.database
@@ -52,28 +55,75 @@ func (this *Client) NewDataset(name string, fields ...string) (influxdb.Dataset,
 		d.precision = this.precision
 	}
 
-	// tags and fields
-	d.tags = make(map[string]string, 0)
+	// tags and fields, which cannot be empty or repeated
+	d.tags = make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if tag == "" {
+			return nil, influxdb.ErrBadParameter
+		} else if _, exists := d.tags[tag]; exists {
+			return nil, influxdb.ErrBadParameter
+		} else {
+			d.tags[tag] = ""
+		}
+	}
 	d.fields = make([]string, 0, len(fields))
-	d.fields = append(d.fields, fields...)
+	for _, field := range fields {
+		if field == "" {
+			return nil, influxdb.ErrBadParameter
+		}
+		for _, existing := range d.fields {
+			if field == existing {
+				return nil, influxdb.ErrBadParameter
+			}
+		}
+		d.fields = append(d.fields, field)
+	}
 
 	// create batch points
-	if points, err := v2.NewBatchPoints(v2.BatchPointsConfig{
-		Database:  d.database,
-		Precision: d.precision,
-	}); err != nil {
+	if err := d.reset(); err != nil {
 		return nil, err
-	} else {
-		d.points = points
 	}
 
 	// return dataset
 	return d, nil
 }
 
-func (this *Client) Write(dataset influxdb.Dataset) error {
-	// TODO
-	return gopi.ErrBadParameter
+// Write sends the points in a dataset to the database and empties the
+// dataset on success, so that it can be re-used for the next batch. When
+// a spool is configured, the points are appended to the spool if the
+// server is unreachable or earlier batches are waiting to be replayed
+func (this *Client) Write(data influxdb.Dataset) error {
+	if this.client == nil {
+		return influxdb.ErrNotConnected
+	}
+	d, ok := data.(*dataset)
+	if ok == false || d == nil {
+		return influxdb.ErrBadParameter
+	}
+	if d.Len() == 0 {
+		return nil
+	}
+	this.log.Debug2("<influxdb.Write>{ database=%v name=%v points=%v precision=%v }", d.database, d.name, d.Len(), d.points.Precision())
+
+	// Preserve ordering with batches already in the spool
+	if this.spool != nil && this.spool.Len() > 0 {
+		if err := this.spool.Append(d.database, d.points); err != nil {
+			return err
+		}
+		return d.reset()
+	}
+
+	// Write the points, and spool them if the server cannot be reached
+	// or the error is temporary. Other errors are returned, so that points
+	// the server rejects are not retried
+	if err := this.client.Write(d.points); err != nil {
+		if err = serverError(err); this.spool == nil || influxdb.IsRetryable(err) == false {
+			return err
+		} else if err := this.spool.Append(d.database, d.points); err != nil {
+			return err
+		}
+	}
+	return d.reset()
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -123,27 +173,41 @@ func (this *dataset) Name() string {
 
 // Len returns the number of rows
 func (this *dataset) Len() uint {
-	// TODO
-	return 0
+	return uint(len(this.points.Points()))
 }
 
 // Partial returns true if either the fetched dataset does
 // not contain all rows, or the dataset has not yet been
 // written to the client
 func (this *dataset) Partial() bool {
-	// TODO
-	return false
+	return this.Len() > 0
 }
 
-func (this *dataset) ValuesAtIndex(uint) (time.Time, []influxdb.Value) {
-	// TODO
-	return time.Time{}, nil
+// ValuesAtIndex returns the timestamp and the field values for a row,
+// in the same order as the field names. Fields which have no value
+// for the row are returned as nil
+func (this *dataset) ValuesAtIndex(i uint) (time.Time, []influxdb.Value) {
+	points := this.points.Points()
+	if i >= uint(len(points)) {
+		return time.Time{}, nil
+	}
+	fields, err := points[i].Fields()
+	if err != nil {
+		return time.Time{}, nil
+	}
+	values := make([]influxdb.Value, len(this.fields))
+	for j, field := range this.fields {
+		if value, exists := fields[field]; exists {
+			values[j] = influxdb.Value(value)
+		}
+	}
+	return points[i].Time(), values
 }
 
 func (this *dataset) AddValues(values ...influxdb.Value) error {
 	if points, err := this.valueMap(values); err != nil {
 		return err
-	} else if pt, err := v2.NewPoint(this.name, this.tags, points); err != nil {
+	} else if pt, err := v2.NewPoint(this.name, this.pointTags(), points); err != nil {
 		return err
 	} else {
 		this.points.AddPoint(pt)
@@ -154,7 +218,7 @@ func (this *dataset) AddValues(values ...influxdb.Value) error {
 func (this *dataset) AddValuesForTimestamp(ts time.Time, values ...influxdb.Value) error {
 	if points, err := this.valueMap(values); err != nil {
 		return err
-	} else if pt, err := v2.NewPoint(this.name, this.tags, points, ts); err != nil {
+	} else if pt, err := v2.NewPoint(this.name, this.pointTags(), points, ts); err != nil {
 		return err
 	} else {
 		this.points.AddPoint(pt)
@@ -166,13 +230,156 @@ func (this *dataset) AddValuesForTimestamp(ts time.Time, values ...influxdb.Valu
 // STRINGIFY
 
 func (this *dataset) String() string {
-	// TODO
-	return fmt.Sprintf("influxdb.Dataset{  }")
+	return fmt.Sprintf("influxdb.Dataset{ name=%v database=%v tags=%v fields=%v len=%v precision=%v }", this.name, this.database, this.tags, this.fields, this.Len(), this.precision)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// reset empties the batch of points
+func (this *dataset) reset() error {
+	if points, err := v2.NewBatchPoints(v2.BatchPointsConfig{
+		Database:  this.database,
+		Precision: writePrecision(this.precision),
+	}); err != nil {
+		return err
+	} else {
+		this.points = points
+		return nil
+	}
+}
+
+// pointTags returns the tags which have non-empty values
+func (this *dataset) pointTags() map[string]string {
+	tags := make(map[string]string, len(this.tags))
+	for k, v := range this.tags {
+		if v != "" {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+// valueMap maps positional values onto field names, ignoring nil values. It
+// returns ErrBadParameter if the number of values does not match the number
+// of fields, a value cannot be converted or all the values are nil
 func (this *dataset) valueMap(values []influxdb.Value) (map[string]interface{}, error) {
-	return nil, nil
+	if len(values) != len(this.fields) {
+		return nil, influxdb.ErrBadParameter
+	}
+	fields := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		} else if value_, err := fieldValue(value, this.precision); err != nil {
+			return nil, err
+		} else {
+			fields[this.fields[i]] = value_
+		}
+	}
+	if len(fields) == 0 {
+		return nil, influxdb.ErrBadParameter
+	}
+	return fields, nil
+}
+
+// fieldValue coerces a value into one of the line protocol
+// types: int64, float64, bool or string. Unsigned integers which
+// overflow int64 are rejected and timestamps are converted into
+// integers at the dataset precision
+func fieldValue(value influxdb.Value, precision string) (interface{}, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		return unsignedValue(uint64(v))
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return unsignedValue(v)
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		return v, nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case time.Duration:
+		return int64(v), nil
+	case time.Time:
+		return v.UnixNano() / int64(precisionDuration(precision)), nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		} else if n, err := v.Float64(); err == nil {
+			return n, nil
+		} else {
+			return nil, influxdb.ErrBadParameter
+		}
+	case fmt.Stringer:
+		return v.String(), nil
+	default:
+		return nil, influxdb.ErrBadParameter
+	}
+}
+
+func unsignedValue(value uint64) (interface{}, error) {
+	if value > math.MaxInt64 {
+		return nil, influxdb.ErrBadParameter
+	} else {
+		return int64(value), nil
+	}
+}
+
+// precisionDuration returns the duration of one unit of precision
+func precisionDuration(precision string) time.Duration {
+	switch precision {
+	case influxdb.PRECISION_MICRO, influxdb.PRECISION_MICRO2:
+		return time.Microsecond
+	case influxdb.PRECISION_MILLI:
+		return time.Millisecond
+	case influxdb.PRECISION_SECOND:
+		return time.Second
+	case influxdb.PRECISION_MINUTE:
+		return time.Minute
+	case influxdb.PRECISION_HOUR:
+		return time.Hour
+	case influxdb.PRECISION_DAY:
+		return time.Hour * 24
+	case influxdb.PRECISION_WEEK:
+		return time.Hour * 24 * 7
+	default:
+		return time.Nanosecond
+	}
+}
+
+// writePrecision returns the precision used for writing points, which is
+// limited to the units the server accepts. Microseconds are written as
+// nanoseconds and days and weeks as hours, which is lossless
+func writePrecision(precision string) string {
+	switch precision {
+	case influxdb.PRECISION_MILLI, influxdb.PRECISION_SECOND, influxdb.PRECISION_MINUTE, influxdb.PRECISION_HOUR:
+		return precision
+	case influxdb.PRECISION_DAY, influxdb.PRECISION_WEEK:
+		return influxdb.PRECISION_HOUR
+	case "":
+		return influxdb.PRECISION_DEFAULT
+	default:
+		return influxdb.PRECISION_NANO
+	}
 }
@@ -0,0 +1,266 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	client "github.com/influxdata/influxdb/client/v2"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// testClient records the batches written, and returns errors for writes
+// and pings when they are set. When limit is set, writes fail once that
+// number of batches have been written
+type testClient struct {
+	writes    []client.BatchPoints
+	limit     int
+	writeErr  error
+	pingErr   error
+	responses []*client.Response
+	queries   []string
+}
+
+func (this *testClient) Ping(timeout time.Duration) (time.Duration, string, error) {
+	return 0, "test", this.pingErr
+}
+
+func (this *testClient) Write(bp client.BatchPoints) error {
+	if this.limit > 0 && len(this.writes) >= this.limit {
+		return errors.New("write failed")
+	} else if this.writeErr != nil {
+		return this.writeErr
+	}
+	this.writes = append(this.writes, bp)
+	return nil
+}
+
+func (this *testClient) Query(q client.Query) (*client.Response, error) {
+	this.queries = append(this.queries, q.Command)
+	if len(this.responses) == 0 {
+		return &client.Response{}, nil
+	}
+	response := this.responses[0]
+	this.responses = this.responses[1:]
+	return response, nil
+}
+
+func (this *testClient) QueryAsChunk(q client.Query) (*client.ChunkedResponse, error) {
+	return nil, influxdb.ErrNotSupported
+}
+
+func (this *testClient) Close() error {
+	return nil
+}
+
+func testDatasetClient(c client.Client, precision string) *Client {
+	return &Client{log: gopi.NullLogger(), client: c, database: "db", precision: precision}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func TestNewDataset_001(t *testing.T) {
+	tests := []struct {
+		database string
+		name     string
+		tags     []string
+		fields   []string
+		ok       bool
+	}{
+		{"db", "cpu", []string{"host"}, []string{"value"}, true},
+		{"db", "cpu", nil, []string{"a", "b"}, true},
+		{"", "cpu", nil, []string{"value"}, false},
+		{"db", "", nil, []string{"value"}, false},
+		{"db", "cpu", nil, nil, false},
+		{"db", "cpu", []string{""}, []string{"value"}, false},
+		{"db", "cpu", []string{"host", "host"}, []string{"value"}, false},
+		{"db", "cpu", nil, []string{"value", ""}, false},
+		{"db", "cpu", nil, []string{"value", "value"}, false},
+	}
+	for i, test := range tests {
+		this := &Client{database: test.database, precision: influxdb.PRECISION_MILLI}
+		if d, err := this.NewDataset(test.name, test.tags, test.fields); test.ok && err != nil {
+			t.Errorf("Test %v: %v", i, err)
+		} else if test.ok == false && err != influxdb.ErrBadParameter {
+			t.Errorf("Test %v: Expected ErrBadParameter, got %v", i, err)
+		} else if test.ok && (d.Name() != test.name || d.(*dataset).Database() != test.database || len(d.Fields()) != len(test.fields)) {
+			t.Errorf("Test %v: Unexpected dataset %v", i, d)
+		}
+	}
+}
+
+func TestDataset_001(t *testing.T) {
+	ts := time.Unix(1500000000, 123456789)
+	tests := []struct {
+		value     influxdb.Value
+		precision string
+		expected  interface{}
+	}{
+		{int(1), influxdb.PRECISION_MILLI, int64(1)},
+		{int8(-2), influxdb.PRECISION_MILLI, int64(-2)},
+		{uint8(3), influxdb.PRECISION_MILLI, int64(3)},
+		{uint64(4), influxdb.PRECISION_MILLI, int64(4)},
+		{float32(1.5), influxdb.PRECISION_MILLI, float64(1.5)},
+		{true, influxdb.PRECISION_MILLI, true},
+		{"on", influxdb.PRECISION_MILLI, "on"},
+		{[]byte("off"), influxdb.PRECISION_MILLI, "off"},
+		{time.Second, influxdb.PRECISION_MILLI, int64(time.Second)},
+		{json.Number("5"), influxdb.PRECISION_MILLI, int64(5)},
+		{json.Number("5.5"), influxdb.PRECISION_MILLI, float64(5.5)},
+		{ts, influxdb.PRECISION_NANO, int64(1500000000123456789)},
+		{ts, influxdb.PRECISION_MILLI, int64(1500000000123)},
+		{ts, influxdb.PRECISION_SECOND, int64(1500000000)},
+		{ts, influxdb.PRECISION_DAY, int64(17361)},
+	}
+	for i, test := range tests {
+		if value, err := fieldValue(test.value, test.precision); err != nil {
+			t.Errorf("Test %v: %v", i, err)
+		} else if value != test.expected {
+			t.Errorf("Test %v: Expected %v (%T), got %v (%T)", i, test.expected, test.expected, value, value)
+		}
+	}
+	for _, value := range []influxdb.Value{uint64(1) << 63, json.Number("x"), struct{}{}} {
+		if _, err := fieldValue(value, influxdb.PRECISION_MILLI); err != influxdb.ErrBadParameter {
+			t.Errorf("Value %v: Expected ErrBadParameter, got %v", value, err)
+		}
+	}
+}
+
+func TestDataset_002(t *testing.T) {
+	tests := map[string]string{
+		"":                        influxdb.PRECISION_DEFAULT,
+		influxdb.PRECISION_NANO:   influxdb.PRECISION_NANO,
+		influxdb.PRECISION_MICRO:  influxdb.PRECISION_NANO,
+		influxdb.PRECISION_MICRO2: influxdb.PRECISION_NANO,
+		influxdb.PRECISION_MILLI:  influxdb.PRECISION_MILLI,
+		influxdb.PRECISION_SECOND: influxdb.PRECISION_SECOND,
+		influxdb.PRECISION_MINUTE: influxdb.PRECISION_MINUTE,
+		influxdb.PRECISION_HOUR:   influxdb.PRECISION_HOUR,
+		influxdb.PRECISION_DAY:    influxdb.PRECISION_HOUR,
+		influxdb.PRECISION_WEEK:   influxdb.PRECISION_HOUR,
+	}
+	for precision, expected := range tests {
+		if value := writePrecision(precision); value != expected {
+			t.Errorf("For precision %q, expected %v, got %v", precision, expected, value)
+		}
+		this := &Client{database: "db", precision: precision}
+		if d, err := this.NewDataset("cpu", nil, []string{"value"}); err != nil {
+			t.Error(err)
+		} else if d.(*dataset).points.Precision() != expected {
+			t.Errorf("For precision %q, expected %v, got %v", precision, expected, d.(*dataset).points.Precision())
+		}
+	}
+}
+
+func TestDataset_003(t *testing.T) {
+	this := &Client{database: "db", precision: influxdb.PRECISION_NANO}
+	d, err := this.NewDataset("cpu", []string{"host", "region"}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetTag("host", "pi")
+	d.SetTag("region", "")
+	for _, values := range [][]influxdb.Value{{1}, {1, 2, 3}, {nil, nil}, {1, struct{}{}}} {
+		if err := d.AddValues(values...); err != influxdb.ErrBadParameter {
+			t.Errorf("Values %v: Expected ErrBadParameter, got %v", values, err)
+		}
+	}
+	ts := time.Unix(1500000000, 0)
+	if err := d.AddValuesForTimestamp(ts, 1.5, nil); err != nil {
+		t.Fatal(err)
+	} else if d.Len() != 1 {
+		t.Errorf("Unexpected length: %v", d.Len())
+	} else if ts_, values := d.ValuesAtIndex(0); ts_.Equal(ts) == false || values[0] != 1.5 || values[1] != nil {
+		t.Errorf("Unexpected values: %v %v", ts_, values)
+	} else if point := d.(*dataset).points.Points()[0]; point.Tags()["host"] != "pi" || len(point.Tags()) != 1 {
+		t.Errorf("Unexpected tags: %v", point.Tags())
+	}
+}
+
+func TestWrite_001(t *testing.T) {
+	c := new(testClient)
+	this := testDatasetClient(c, influxdb.PRECISION_SECOND)
+	d, err := this.NewDataset("cpu", nil, []string{"value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Writing an empty dataset does nothing
+	if err := this.Write(d); err != nil {
+		t.Error(err)
+	} else if len(c.writes) != 0 {
+		t.Error("Unexpected write for empty dataset")
+	}
+
+	// Dataset is reset after a successful write
+	d.AddValuesForTimestamp(time.Unix(1500000000, 0), 1)
+	d.AddValuesForTimestamp(time.Unix(1500000001, 0), 2)
+	if err := this.Write(d); err != nil {
+		t.Error(err)
+	} else if len(c.writes) != 1 || len(c.writes[0].Points()) != 2 {
+		t.Errorf("Unexpected writes: %v", c.writes)
+	} else if c.writes[0].Database() != "db" || c.writes[0].Precision() != influxdb.PRECISION_SECOND {
+		t.Errorf("Unexpected batch: database=%v precision=%v", c.writes[0].Database(), c.writes[0].Precision())
+	} else if d.Len() != 0 {
+		t.Errorf("Expected dataset to be reset, len=%v", d.Len())
+	}
+
+	// Dataset is not reset when the write fails
+	c.writeErr = errors.New(`{"error":"database not found: \"db\""}`)
+	d.AddValues(3)
+	if err := this.Write(d); err != influxdb.ErrDatabaseNotFound {
+		t.Errorf("Expected ErrDatabaseNotFound, got %v", err)
+	} else if d.Len() != 1 {
+		t.Errorf("Expected dataset to be retained, len=%v", d.Len())
+	}
+
+	// Writes fail when not connected, or for other datasets
+	if err := this.Write(nil); err != influxdb.ErrBadParameter {
+		t.Errorf("Expected ErrBadParameter, got %v", err)
+	}
+	this.client = nil
+	if err := this.Write(d); err != influxdb.ErrNotConnected {
+		t.Errorf("Expected ErrNotConnected, got %v", err)
+	} else if strings.Contains(d.(*dataset).String(), "len=1") == false {
+		t.Errorf("Unexpected dataset: %v", d)
+	}
+}
+
+func TestWrite_002(t *testing.T) {
+	path := testTempDir(t)
+	defer os.RemoveAll(path)
+
+	c := &testClient{pingErr: errors.New("unreachable")}
+	this := testDatasetClient(c, influxdb.PRECISION_SECOND)
+	this.spool = testSpool(t, path, 0, 0)
+	d, err := this.NewDataset("cpu", nil, []string{"value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Points which the server rejects are returned and not spooled
+	c.writeErr = errors.New(`{"error":"unable to parse 'cpu value=': missing field value"}`)
+	d.AddValues(1)
+	if err := this.Write(d); err == nil || influxdb.IsRetryable(err) {
+		t.Errorf("Unexpected error: %v", err)
+	} else if this.spool.Len() != 0 || d.Len() != 1 {
+		t.Errorf("Unexpected spool length=%v dataset length=%v", this.spool.Len(), d.Len())
+	}
+
+	// Points are spooled when the server cannot be reached
+	c.writeErr = &url.Error{Op: "Post", URL: "http://influxdb/write", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+	if err := this.Write(d); err != nil {
+		t.Error(err)
+	} else if this.spool.Len() != 1 || d.Len() != 0 {
+		t.Errorf("Unexpected spool length=%v dataset length=%v", this.spool.Len(), d.Len())
+	}
+}
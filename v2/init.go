@@ -27,6 +27,9 @@ func init() {
 			config.AppFlags.FlagString("influx.user", "", "User")
 			config.AppFlags.FlagString("influx.password", "", "Password")
 			config.AppFlags.FlagDuration("influx.timeout", 0, "Communication timeout")
+			config.AppFlags.FlagString("influx.spool", "", "Write spool directory")
+			config.AppFlags.FlagUint("influx.spool.size", 0, "Maximum write spool size in megabytes")
+			config.AppFlags.FlagDuration("influx.spool.age", 0, "Maximum age of spooled writes")
 		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
 			host, _ := app.AppFlags.GetString("influx.host")
@@ -36,14 +39,20 @@ func init() {
 			user, _ := app.AppFlags.GetString("influx.user")
 			password, _ := app.AppFlags.GetString("influx.password")
 			timeout, _ := app.AppFlags.GetDuration("influx.timeout")
+			spool, _ := app.AppFlags.GetString("influx.spool")
+			spool_size, _ := app.AppFlags.GetUint("influx.spool.size")
+			spool_age, _ := app.AppFlags.GetDuration("influx.spool.age")
 			return gopi.Open(Config{
-				Host:      host,
-				Port:      port,
-				SSL:       ssl,
-				SSLVerify: sslverify,
-				Username:  user,
-				Password:  password,
-				Timeout:   timeout,
+				Host:         host,
+				Port:         port,
+				SSL:          ssl,
+				SSLVerify:    sslverify,
+				Username:     user,
+				Password:     password,
+				Timeout:      timeout,
+				SpoolPath:    spool,
+				SpoolMaxSize: int64(spool_size) * 1024 * 1024,
+				SpoolMaxAge:  spool_age,
 			}, app.Logger)
 		},
 	})
@@ -0,0 +1,457 @@
+/*
+	InfluxDB client
+	(c) Copyright David Thorpe 2017
+	All Rights Reserved
+
+	For Licensing and Usage information, please see LICENSE file
+*/
+
+package v2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gopi "github.com/djthorpe/gopi"
+	influxdb "github.com/djthorpe/influxdb"
+	client "github.com/influxdata/influxdb/client/v2"
+	models "github.com/influxdata/influxdb/models"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SpoolStats returns information about the number of batches waiting
+// to be written to the database
+type SpoolStats struct {
+	Segments uint
+	Records  uint
+	Bytes    int64
+	Dropped  uint64
+	Oldest   time.Time
+}
+
+// spool is a write-ahead log of batches, stored as a sequence of
+// segment files in a directory
+type spool struct {
+	log      gopi.Logger
+	path     string
+	maxsize  int64
+	maxage   time.Duration
+	lock     sync.Mutex
+	segments []*segment
+	sealed   bool
+	closed   bool
+	dropped  uint64
+	notify   chan struct{}
+}
+
+// segment is a single file in the spool. Each record in the segment
+// consists of a length, a checksum and a payload, which is the database
+// name followed by points in line protocol with nanosecond precision.
+// A record with an empty payload marks the next record as replayed, so
+// that records are not written again when the spool is re-opened
+type segment struct {
+	id       uint64
+	path     string
+	size     int64
+	records  uint
+	modified time.Time
+	replayed uint
+	attempts uint
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	SPOOL_SEGMENT_SIZE  int64         = 1024 * 1024
+	SPOOL_EXT           string        = ".spool"
+	SPOOL_BACKOFF_MIN   time.Duration = time.Second
+	SPOOL_BACKOFF_MAX   time.Duration = 5 * time.Minute
+	SPOOL_MAX_ATTEMPTS  uint          = 3
+	spool_header_length int64         = 8
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN SPOOL
+
+// openSpool creates the spool directory if necessary, and recovers
+// existing segments, truncating any partially written records
+func openSpool(path string, maxsize int64, maxage time.Duration, log gopi.Logger) (*spool, error) {
+	this := new(spool)
+	this.log = log
+	this.path = path
+	this.maxsize = maxsize
+	this.maxage = maxage
+	this.notify = make(chan struct{}, 1)
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() || strings.HasSuffix(file.Name(), SPOOL_EXT) == false {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), SPOOL_EXT), 16, 64)
+		if err != nil {
+			continue
+		}
+		s := &segment{id: id, path: filepath.Join(path, file.Name()), modified: file.ModTime()}
+		if err := s.recover(log); err != nil {
+			return nil, err
+		} else if s.records == 0 {
+			os.Remove(s.path)
+		} else {
+			this.segments = append(this.segments, s)
+		}
+	}
+	sort.Slice(this.segments, func(i, j int) bool {
+		return this.segments[i].id < this.segments[j].id
+	})
+
+	// Existing segments are not appended to
+	this.sealed = true
+	this.expire()
+
+	return this, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Len returns the number of records waiting to be replayed
+func (this *spool) Len() uint {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	records := uint(0)
+	for _, s := range this.segments {
+		records += s.records - s.replayed
+	}
+	return records
+}
+
+// Stats returns the current depth of the spool
+func (this *spool) Stats() SpoolStats {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	stats := SpoolStats{Segments: uint(len(this.segments)), Dropped: this.dropped}
+	for _, s := range this.segments {
+		stats.Records += s.records - s.replayed
+		stats.Bytes += s.size
+	}
+	if len(this.segments) > 0 {
+		stats.Oldest = this.segments[0].modified
+	}
+	return stats
+}
+
+// Close seals the spool, so that no more records are appended. Records
+// which have not been replayed are kept on disk until the spool is re-opened
+func (this *spool) Close() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.sealed = true
+	this.closed = true
+	this.segments = nil
+	return nil
+}
+
+// Append a batch of points to the spool
+func (this *spool) Append(database string, points client.BatchPoints) error {
+	payload := encodeRecord(database, points.Points())
+	record := make([]byte, spool_header_length, spool_header_length+int64(len(payload)))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	record = append(record, payload...)
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.closed {
+		return influxdb.ErrNotConnected
+	}
+
+	// Create a new segment if necessary
+	s := this.current()
+	if s == nil || s.size+int64(len(record)) > SPOOL_SEGMENT_SIZE {
+		id := uint64(time.Now().UnixNano())
+		if s != nil && id <= s.id {
+			id = s.id + 1
+		}
+		s = &segment{id: id, path: filepath.Join(this.path, fmt.Sprintf("%016X%v", id, SPOOL_EXT))}
+		this.segments = append(this.segments, s)
+		this.sealed = false
+	}
+
+	// Append the record and sync to disk
+	if err := s.append(record, os.O_CREATE); err != nil {
+		return err
+	}
+	s.records++
+	s.modified = time.Now()
+	this.log.Debug2("<influxdb.Spool>Append{ database=%v points=%v segment=%v }", database, len(points.Points()), s.path)
+
+	// Enforce size and age limits
+	this.expire()
+
+	// Signal the replay task
+	select {
+	case this.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// REPLAY
+
+// replay writes the oldest segment to the server, and removes it once all
+// records have been written. It returns the number of records replayed
+func (this *spool) replay(c client.Client) (uint, error) {
+	this.lock.Lock()
+	this.expire()
+	if len(this.segments) == 0 {
+		this.lock.Unlock()
+		return 0, nil
+	}
+	s := this.segments[0]
+	if s == this.current() {
+		this.sealed = true
+	}
+	this.lock.Unlock()
+
+	// Read records from the segment
+	payloads, err := s.read()
+	if err != nil {
+		return 0, err
+	}
+
+	// Write records which have not already been replayed
+	count := uint(0)
+	for i := s.replayed; i < uint(len(payloads)); i++ {
+		if bp, err := decodeRecord(payloads[i]); err != nil {
+			this.log.Warn("Spool: Discarding record in %v: %v", s.path, err)
+			this.markReplayed(s, true)
+		} else if err := c.Write(bp); err != nil {
+			if _, _, err_ := c.Ping(0); err_ != nil {
+				return count, err
+			} else if s.attempts++; s.attempts >= SPOOL_MAX_ATTEMPTS {
+				this.log.Warn("Spool: Discarding record in %v: %v", s.path, err)
+				this.markReplayed(s, true)
+			} else {
+				return count, err
+			}
+		} else {
+			this.markReplayed(s, false)
+			count++
+		}
+	}
+
+	// Remove the segment
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if len(this.segments) > 0 && this.segments[0] == s {
+		this.segments = this.segments[1:]
+	}
+	if err := os.Remove(s.path); err != nil && os.IsNotExist(err) == false {
+		return count, err
+	}
+	return count, nil
+}
+
+func (this *spool) markReplayed(s *segment, dropped bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if err := s.mark(); err != nil {
+		this.log.Warn("Spool: Unable to mark record as replayed in %v: %v", s.path, err)
+	}
+	s.replayed++
+	s.attempts = 0
+	if dropped {
+		this.dropped++
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// current returns the segment which is being appended to, or nil
+func (this *spool) current() *segment {
+	if this.sealed || len(this.segments) == 0 {
+		return nil
+	} else {
+		return this.segments[len(this.segments)-1]
+	}
+}
+
+// expire removes the oldest segments when the spool is larger than
+// the maximum size, and any segments older than the maximum age
+func (this *spool) expire() {
+	size := int64(0)
+	for _, s := range this.segments {
+		size += s.size
+	}
+	for len(this.segments) > 0 {
+		s := this.segments[0]
+		if this.maxsize > 0 && size > this.maxsize {
+			this.log.Warn("Spool: Size exceeded, discarding %v", s.path)
+		} else if this.maxage > 0 && time.Since(s.modified) > this.maxage {
+			this.log.Warn("Spool: Age exceeded, discarding %v", s.path)
+		} else {
+			break
+		}
+		size -= s.size
+		this.dropped += uint64(s.records - s.replayed)
+		this.segments = this.segments[1:]
+		if s == this.current() || len(this.segments) == 0 {
+			this.sealed = true
+		}
+		os.Remove(s.path)
+	}
+}
+
+// append a record to the segment file and sync to disk
+func (s *segment) append(record []byte, flag int) error {
+	fh, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|flag, 0644)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	if _, err := fh.Write(record); err != nil {
+		// Remove the partial record
+		fh.Truncate(s.size)
+		return err
+	} else if err := fh.Sync(); err != nil {
+		return err
+	}
+	s.size += int64(len(record))
+	return nil
+}
+
+// mark appends a record with an empty payload, which marks a record
+// as replayed. The modification time is not changed, so that the age
+// of the segment is preserved
+func (s *segment) mark() error {
+	if err := s.append(make([]byte, spool_header_length), 0); err != nil {
+		return err
+	}
+	return os.Chtimes(s.path, s.modified, s.modified)
+}
+
+// read returns all the valid record payloads in a segment
+func (s *segment) read() ([][]byte, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	payloads, _, _ := scanRecords(data)
+	return payloads, nil
+}
+
+// recover reads the segment and truncates the file after the last
+// complete record, which may have been partially written before a crash
+func (s *segment) recover(log gopi.Logger) error {
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	payloads, replayed, valid := scanRecords(data)
+	if valid < int64(len(data)) {
+		log.Warn("Spool: Truncating %v from %v to %v bytes", s.path, len(data), valid)
+		if err := os.Truncate(s.path, valid); err != nil {
+			return err
+		}
+	}
+	s.size = valid
+	s.records = uint(len(payloads))
+	if s.replayed = replayed; s.replayed > s.records {
+		s.replayed = s.records
+	}
+	return nil
+}
+
+// scanRecords returns the payloads of the records, the number of records
+// marked as replayed and the length of the data which consists of complete
+// records with valid checksums
+func scanRecords(data []byte) ([][]byte, uint, int64) {
+	payloads := make([][]byte, 0)
+	replayed := uint(0)
+	offset := int64(0)
+	for offset+spool_header_length <= int64(len(data)) {
+		length := int64(binary.BigEndian.Uint32(data[offset : offset+4]))
+		checksum := binary.BigEndian.Uint32(data[offset+4 : offset+8])
+		end := offset + spool_header_length + length
+		if end > int64(len(data)) {
+			break
+		}
+		payload := data[offset+spool_header_length : end]
+		if crc32.ChecksumIEEE(payload) != checksum {
+			break
+		}
+		if len(payload) == 0 {
+			replayed++
+		} else {
+			payloads = append(payloads, payload)
+		}
+		offset = end
+	}
+	return payloads, replayed, offset
+}
+
+// encodeRecord returns the database name on the first line followed by
+// points in line protocol. Points without a timestamp are given the
+// current time
+func encodeRecord(database string, points []*client.Point) []byte {
+	buf := new(bytes.Buffer)
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	buf.WriteString(database)
+	buf.WriteByte('\n')
+	for _, pt := range points {
+		buf.WriteString(pt.String())
+		if pt.Time().IsZero() {
+			buf.WriteByte(' ')
+			buf.WriteString(now)
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// decodeRecord returns a batch of points from a record
+func decodeRecord(payload []byte) (client.BatchPoints, error) {
+	i := bytes.IndexByte(payload, '\n')
+	if i < 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	bp, err := client.NewBatchPoints(client.BatchPointsConfig{
+		Database:  string(payload[:i]),
+		Precision: influxdb.PRECISION_NANO,
+	})
+	if err != nil {
+		return nil, err
+	}
+	points, err := models.ParsePointsWithPrecision(payload[i+1:], time.Now(), "n")
+	if err != nil {
+		return nil, err
+	}
+	for _, pt := range points {
+		bp.AddPoint(client.NewPointFrom(pt))
+	}
+	return bp, nil
+}
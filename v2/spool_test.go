@@ -0,0 +1,270 @@
+package v2
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	client "github.com/influxdata/influxdb/client/v2"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func testSpool(t *testing.T, path string, maxsize int64, maxage time.Duration) *spool {
+	if s, err := openSpool(path, maxsize, maxage, gopi.NullLogger()); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return s
+	}
+}
+
+// testBatch returns a batch with a single point, which has the value v
+func testBatch(t *testing.T, v int) client.BatchPoints {
+	bp, err := client.NewBatchPoints(client.BatchPointsConfig{Database: "db", Precision: influxdb.PRECISION_NANO})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := client.NewPoint("cpu", nil, map[string]interface{}{"v": v}, time.Unix(1500000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bp.AddPoint(pt)
+	return bp
+}
+
+// testValues returns the value of the first point in each batch written
+func testValues(c *testClient) []interface{} {
+	values := make([]interface{}, len(c.writes))
+	for i, bp := range c.writes {
+		if fields, err := bp.Points()[0].Fields(); err == nil {
+			values[i] = fields["v"]
+		}
+	}
+	return values
+}
+
+// testSegments returns the paths of the segment files in the spool
+func testSegments(t *testing.T, path string) []string {
+	files, err := filepath.Glob(filepath.Join(path, "*"+SPOOL_EXT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func testTempDir(t *testing.T) string {
+	path, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func TestSpool_001(t *testing.T) {
+	path := testTempDir(t)
+	defer os.RemoveAll(path)
+
+	// Records are replayed in order, across segments
+	s := testSpool(t, path, 0, 0)
+	s.Append("db", testBatch(t, 1))
+	s.Append("db", testBatch(t, 2))
+	s = testSpool(t, path, 0, 0)
+	s.Append("db", testBatch(t, 3))
+	if s.Len() != 3 {
+		t.Errorf("Unexpected length: %v", s.Len())
+	} else if segments := testSegments(t, path); len(segments) != 2 {
+		t.Errorf("Unexpected segments: %v", segments)
+	}
+
+	c := new(testClient)
+	for s.Len() > 0 {
+		if _, err := s.replay(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if values := testValues(c); len(values) != 3 || values[0] != int64(1) || values[1] != int64(2) || values[2] != int64(3) {
+		t.Errorf("Unexpected values: %v", values)
+	} else if c.writes[0].Database() != "db" {
+		t.Errorf("Unexpected database: %v", c.writes[0].Database())
+	} else if segments := testSegments(t, path); len(segments) != 0 {
+		t.Errorf("Expected segments to be removed: %v", segments)
+	}
+}
+
+func TestSpool_002(t *testing.T) {
+	path := testTempDir(t)
+	defer os.RemoveAll(path)
+
+	// The replay position is kept when the spool is re-opened
+	s := testSpool(t, path, 0, 0)
+	for i := 1; i <= 4; i++ {
+		s.Append("db", testBatch(t, i))
+	}
+	c := &testClient{limit: 2, pingErr: errors.New("unreachable")}
+	if n, err := s.replay(c); err == nil || n != 2 {
+		t.Errorf("Expected partial replay, got n=%v err=%v", n, err)
+	}
+
+	s = testSpool(t, path, 0, 0)
+	if s.Len() != 2 {
+		t.Errorf("Unexpected length after re-open: %v", s.Len())
+	}
+	c.limit = 0
+	if n, err := s.replay(c); err != nil || n != 2 {
+		t.Errorf("Expected replay of remaining records, got n=%v err=%v", n, err)
+	} else if values := testValues(c); len(values) != 4 || values[2] != int64(3) || values[3] != int64(4) {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestSpool_003(t *testing.T) {
+	path := testTempDir(t)
+	defer os.RemoveAll(path)
+
+	// A partially written record is truncated when the spool is re-opened
+	s := testSpool(t, path, 0, 0)
+	s.Append("db", testBatch(t, 1))
+	s.Append("db", testBatch(t, 2))
+	segment := s.segments[0]
+	size := segment.size
+	if err := os.Truncate(segment.path, size-3); err != nil {
+		t.Fatal(err)
+	}
+
+	s = testSpool(t, path, 0, 0)
+	if s.Len() != 1 {
+		t.Errorf("Unexpected length: %v", s.Len())
+	} else if info, err := os.Stat(segment.path); err != nil {
+		t.Error(err)
+	} else if info.Size() != s.segments[0].size || info.Size() >= size {
+		t.Errorf("Expected segment to be truncated, size=%v", info.Size())
+	}
+
+	c := new(testClient)
+	if _, err := s.replay(c); err != nil {
+		t.Error(err)
+	} else if values := testValues(c); len(values) != 1 || values[0] != int64(1) {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestSpool_004(t *testing.T) {
+	path := testTempDir(t)
+	defer os.RemoveAll(path)
+
+	// Records from a checksum mismatch onwards are discarded
+	s := testSpool(t, path, 0, 0)
+	for i := 1; i <= 3; i++ {
+		s.Append("db", testBatch(t, i))
+	}
+	segment := s.segments[0]
+	data, err := ioutil.ReadFile(segment.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloads, _, _ := scanRecords(data)
+	first := spool_header_length + int64(len(payloads[0]))
+	data[first+spool_header_length] ^= 0xFF
+	if err := ioutil.WriteFile(segment.path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s = testSpool(t, path, 0, 0)
+	if s.Len() != 1 {
+		t.Errorf("Unexpected length: %v", s.Len())
+	} else if s.segments[0].size != first {
+		t.Errorf("Unexpected size: %v", s.segments[0].size)
+	}
+}
+
+func TestSpool_005(t *testing.T) {
+	path := testTempDir(t)
+	defer os.RemoveAll(path)
+
+	// The oldest segments are discarded when the spool is too large
+	for i := 1; i <= 3; i++ {
+		s := testSpool(t, path, 0, 0)
+		s.Append("db", testBatch(t, i))
+	}
+	s := testSpool(t, path, 0, 0)
+	if len(s.segments) != 3 {
+		t.Fatalf("Unexpected segments: %v", len(s.segments))
+	}
+	size := s.segments[0].size
+	s = testSpool(t, path, size*2, 0)
+	if s.Len() != 2 || s.Stats().Dropped != 1 {
+		t.Errorf("Unexpected stats: %+v", s.Stats())
+	} else if segments := testSegments(t, path); len(segments) != 2 {
+		t.Errorf("Unexpected segments: %v", segments)
+	}
+
+	c := new(testClient)
+	for s.Len() > 0 {
+		if _, err := s.replay(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if values := testValues(c); len(values) != 2 || values[0] != int64(2) || values[1] != int64(3) {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestSpool_006(t *testing.T) {
+	path := testTempDir(t)
+	defer os.RemoveAll(path)
+
+	// Segments older than the maximum age are discarded
+	s := testSpool(t, path, 0, 0)
+	s.Append("db", testBatch(t, 1))
+	s = testSpool(t, path, 0, 0)
+	s.Append("db", testBatch(t, 2))
+	s.Append("db", testBatch(t, 3))
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(s.segments[0].path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	recent := time.Now().Add(-30 * time.Minute)
+	if err := os.Chtimes(s.segments[1].path, recent, recent); err != nil {
+		t.Fatal(err)
+	}
+
+	s = testSpool(t, path, 0, time.Hour)
+	if s.Len() != 2 || s.Stats().Dropped != 1 {
+		t.Errorf("Unexpected stats: %+v", s.Stats())
+	}
+
+	// Marking a record as replayed does not change the age of a segment
+	c := &testClient{limit: 1, pingErr: errors.New("unreachable")}
+	if n, _ := s.replay(c); n != 1 {
+		t.Errorf("Expected one record replayed, got %v", n)
+	} else if info, err := os.Stat(s.segments[0].path); err != nil {
+		t.Error(err)
+	} else if info.ModTime().Unix() != recent.Unix() {
+		t.Errorf("Unexpected modification time: %v", info.ModTime())
+	}
+}
+
+func TestSpool_007(t *testing.T) {
+	path := testTempDir(t)
+	defer os.RemoveAll(path)
+
+	// Records cannot be appended once the spool is closed, but are kept
+	// for when it is re-opened
+	s := testSpool(t, path, 0, 0)
+	s.Append("db", testBatch(t, 1))
+	if err := s.Close(); err != nil {
+		t.Error(err)
+	} else if err := s.Append("db", testBatch(t, 2)); err != influxdb.ErrNotConnected {
+		t.Errorf("Expected ErrNotConnected, got %v", err)
+	} else if s = testSpool(t, path, 0, 0); s.Len() != 1 {
+		t.Errorf("Unexpected length: %v", s.Len())
+	}
+}
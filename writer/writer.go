@@ -0,0 +1,410 @@
+/*
+	InfluxDB client
+	(c) Copyright David Thorpe 2017
+	All Rights Reserved
+
+	For Licensing and Usage information, please see LICENSE file
+*/
+
+package writer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gopi "github.com/djthorpe/gopi"
+	influxdb "github.com/djthorpe/influxdb"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Overflow defines what happens when points are added to a full buffer
+type Overflow uint
+
+// ErrorHandler is called with each group of points in a batch which could
+// not be written, and the error returned by the client. Points which were
+// written are not included
+type ErrorHandler func(points []*influxdb.Point, err error)
+
+// Config defines the configuration parameters for the background writer
+type Config struct {
+	Client       influxdb.Client
+	BatchSize    uint
+	BufferSize   uint
+	Interval     time.Duration
+	Overflow     Overflow
+	ErrorHandler ErrorHandler
+}
+
+// Writer buffers points from many goroutines and writes them to the
+// database when a batch is full, or when the flush interval elapses
+type Writer struct {
+	log      gopi.Logger
+	client   influxdb.Client
+	batch    uint
+	size     uint
+	interval time.Duration
+	overflow Overflow
+	handler  ErrorHandler
+
+	// buffer and state protected by the mutex
+	lock    sync.Mutex
+	cond    *sync.Cond
+	buffer  []*influxdb.Point
+	dropped uint64
+	closed  bool
+
+	// writes to the client are serialized
+	write sync.Mutex
+
+	// background task signals
+	kick chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// failure is a group of points which could not be written
+type failure struct {
+	points []*influxdb.Point
+	err    error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Add blocks until there is space in the buffer
+	OVERFLOW_BLOCK Overflow = iota
+	// The oldest point in the buffer is discarded
+	OVERFLOW_DROP_OLDEST
+	// The point being added is discarded
+	OVERFLOW_DROP_NEWEST
+)
+
+const (
+	DEFAULT_BATCH_SIZE  uint          = 1000
+	DEFAULT_BUFFER_SIZE uint          = 10000
+	DEFAULT_INTERVAL    time.Duration = time.Second
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open returns a background writer object
+func (config Config) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug2("<influxdb.Writer>Open{ batch=%v buffer=%v interval=%v overflow=%v }", config.BatchSize, config.BufferSize, config.Interval, config.Overflow)
+
+	this := new(Writer)
+	this.log = log
+	this.client = config.Client
+	this.batch = config.BatchSize
+	this.size = config.BufferSize
+	this.interval = config.Interval
+	this.overflow = config.Overflow
+	this.handler = config.ErrorHandler
+
+	// Set defaults and check parameters
+	if this.batch == 0 {
+		this.batch = DEFAULT_BATCH_SIZE
+	}
+	if this.size == 0 {
+		this.size = DEFAULT_BUFFER_SIZE
+	}
+	if this.interval == 0 {
+		this.interval = DEFAULT_INTERVAL
+	}
+	if this.client == nil || this.size < this.batch || this.interval < 0 {
+		return nil, influxdb.ErrBadParameter
+	}
+	switch this.overflow {
+	case OVERFLOW_BLOCK, OVERFLOW_DROP_OLDEST, OVERFLOW_DROP_NEWEST:
+		break
+	default:
+		return nil, influxdb.ErrBadParameter
+	}
+
+	// Create the buffer and start the background task
+	this.cond = sync.NewCond(&this.lock)
+	this.buffer = make([]*influxdb.Point, 0, this.size)
+	this.kick = make(chan struct{}, 1)
+	this.stop = make(chan struct{})
+	this.done = make(chan struct{})
+	go this.run()
+
+	// Return success
+	return this, nil
+}
+
+// Close stops the background task and writes any remaining points
+// to the database. Any blocked calls to Add return ErrNotConnected
+func (this *Writer) Close() error {
+	this.log.Debug2("<influxdb.Writer>Close")
+
+	this.lock.Lock()
+	if this.closed {
+		this.lock.Unlock()
+		return nil
+	}
+	this.closed = true
+	this.cond.Broadcast()
+	this.lock.Unlock()
+
+	// Stop background task and drain the buffer
+	close(this.stop)
+	<-this.done
+	return this.flush(1)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Add points to the buffer. Points without a timestamp are given the current
+// time. When the buffer is full the overflow policy determines whether Add
+// blocks or a point is discarded
+func (this *Writer) Add(points ...*influxdb.Point) error {
+	// Check points before adding any of them
+	for _, point := range points {
+		if point == nil || point.Name == "" || len(point.Fields) == 0 {
+			return influxdb.ErrBadParameter
+		}
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	now := time.Now()
+FOR_LOOP:
+	for _, point := range points {
+		for uint(len(this.buffer)) >= this.size {
+			if this.closed {
+				return influxdb.ErrNotConnected
+			}
+			switch this.overflow {
+			case OVERFLOW_DROP_OLDEST:
+				copy(this.buffer, this.buffer[1:])
+				this.buffer = this.buffer[:len(this.buffer)-1]
+				this.dropped++
+			case OVERFLOW_DROP_NEWEST:
+				this.dropped++
+				continue FOR_LOOP
+			default:
+				this.signal()
+				this.cond.Wait()
+			}
+		}
+		if this.closed {
+			return influxdb.ErrNotConnected
+		}
+		if point.Time.IsZero() {
+			point_ := *point
+			point_.Time = now
+			point = &point_
+		}
+		this.buffer = append(this.buffer, point)
+	}
+
+	// Signal the background task when there is a full batch
+	if uint(len(this.buffer)) >= this.batch {
+		this.signal()
+	}
+
+	return nil
+}
+
+// Flush writes all buffered points to the database, and returns the
+// first error encountered
+func (this *Writer) Flush() error {
+	return this.flush(1)
+}
+
+// Len returns the number of points in the buffer
+func (this *Writer) Len() uint {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return uint(len(this.buffer))
+}
+
+// Dropped returns the number of points which have been discarded
+// because the buffer was full
+func (this *Writer) Dropped() uint64 {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.dropped
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Writer) String() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return fmt.Sprintf("<influxdb.Writer>{ batch=%v buffer=%v/%v interval=%v overflow=%v dropped=%v }", this.batch, len(this.buffer), this.size, this.interval, this.overflow, this.dropped)
+}
+
+func (o Overflow) String() string {
+	switch o {
+	case OVERFLOW_BLOCK:
+		return "OVERFLOW_BLOCK"
+	case OVERFLOW_DROP_OLDEST:
+		return "OVERFLOW_DROP_OLDEST"
+	case OVERFLOW_DROP_NEWEST:
+		return "OVERFLOW_DROP_NEWEST"
+	default:
+		return "[?? Invalid Overflow value]"
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// BACKGROUND TASK
+
+func (this *Writer) run() {
+	ticker := time.NewTicker(this.interval)
+	defer ticker.Stop()
+	defer close(this.done)
+
+	for {
+		select {
+		case <-this.kick:
+			this.flush(this.batch)
+		case <-ticker.C:
+			this.flush(1)
+		case <-this.stop:
+			return
+		}
+	}
+}
+
+// signal wakes up the background task without blocking
+func (this *Writer) signal() {
+	select {
+	case this.kick <- struct{}{}:
+	default:
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// flush writes batches while there are at least min points in the
+// buffer, and returns the first error
+func (this *Writer) flush(min uint) error {
+	this.write.Lock()
+	defer this.write.Unlock()
+
+	var result error
+	for {
+		points := this.take(min)
+		if len(points) == 0 {
+			return result
+		}
+		for _, failure := range this.writePoints(points) {
+			this.log.Debug("<influxdb.Writer>Flush: %v (%v points)", failure.err, len(failure.points))
+			if this.handler != nil {
+				this.handler(failure.points, failure.err)
+			}
+			if result == nil {
+				result = failure.err
+			}
+		}
+	}
+}
+
+// take removes up to one batch of points from the buffer if there
+// are at least min points, and wakes any blocked calls to Add
+func (this *Writer) take(min uint) []*influxdb.Point {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	n := uint(len(this.buffer))
+	if n == 0 || n < min {
+		return nil
+	} else if n > this.batch {
+		n = this.batch
+	}
+	points := make([]*influxdb.Point, n)
+	copy(points, this.buffer[:n])
+	copy(this.buffer, this.buffer[n:])
+	this.buffer = this.buffer[:uint(len(this.buffer))-n]
+	this.cond.Broadcast()
+	return points
+}
+
+// writePoints groups points into datasets with the same measurement,
+// tags and fields and writes each dataset to the client. It returns the
+// groups which could not be written
+func (this *Writer) writePoints(points []*influxdb.Point) []failure {
+	keys := make([]string, 0)
+	groups := make(map[string][]*influxdb.Point)
+	for _, point := range points {
+		key := seriesKey(point)
+		if _, exists := groups[key]; exists == false {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], point)
+	}
+	failures := make([]failure, 0)
+	for _, key := range keys {
+		if err := this.writeGroup(groups[key]); err != nil {
+			failures = append(failures, failure{groups[key], err})
+		}
+	}
+	return failures
+}
+
+// writeGroup writes points with the same measurement, tags and fields
+// as a single dataset
+func (this *Writer) writeGroup(group []*influxdb.Point) error {
+	tags := sortedKeys(group[0].Tags)
+	fields := make([]string, 0, len(group[0].Fields))
+	for k := range group[0].Fields {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	dataset, err := this.client.NewDataset(group[0].Name, tags, fields)
+	if err != nil {
+		return err
+	}
+	for k, v := range group[0].Tags {
+		dataset.SetTag(k, v)
+	}
+	values := make([]influxdb.Value, len(fields))
+	for _, point := range group {
+		for i, field := range fields {
+			values[i] = point.Fields[field]
+		}
+		if err := dataset.AddValuesForTimestamp(point.Time, values...); err != nil {
+			return err
+		}
+	}
+	return this.client.Write(dataset)
+}
+
+// seriesKey returns a key for the measurement, tag values and field
+// names of a point
+func seriesKey(point *influxdb.Point) string {
+	tags := sortedKeys(point.Tags)
+	key := make([]string, 0, len(tags)+len(point.Fields)+1)
+	key = append(key, point.Name)
+	for _, k := range tags {
+		key = append(key, k+"\x00"+point.Tags[k])
+	}
+	fields := make([]string, 0, len(point.Fields))
+	for k := range point.Fields {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	return strings.Join(key, "\x00") + "\x01" + strings.Join(fields, "\x00")
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,260 @@
+package writer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi"
+	"github.com/djthorpe/influxdb"
+	"github.com/djthorpe/influxdb/mock"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// testDataset records the rows added to it as points
+type testDataset struct {
+	influxdb.Dataset
+	name   string
+	tags   map[string]string
+	fields []string
+	points []*influxdb.Point
+}
+
+// testClient records the points written, and fails writes for the
+// measurement named by fail
+type testClient struct {
+	*mock.Driver
+	lock    sync.Mutex
+	fail    string
+	written []*influxdb.Point
+	writes  uint
+}
+
+func (this *testDataset) SetTag(key, value string) {
+	this.tags[key] = value
+}
+
+func (this *testDataset) AddValuesForTimestamp(ts time.Time, values ...influxdb.Value) error {
+	if len(values) != len(this.fields) {
+		return influxdb.ErrBadParameter
+	}
+	point := &influxdb.Point{Name: this.name, Tags: this.tags, Fields: make(map[string]influxdb.Value), Time: ts}
+	for i, field := range this.fields {
+		point.Fields[field] = values[i]
+	}
+	this.points = append(this.points, point)
+	return nil
+}
+
+func (this *testClient) NewDataset(name string, tags, fields []string) (influxdb.Dataset, error) {
+	return &testDataset{name: name, tags: make(map[string]string), fields: fields}, nil
+}
+
+func (this *testClient) Write(data influxdb.Dataset) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	dataset := data.(*testDataset)
+	if dataset.name == this.fail {
+		return errors.New("write failed")
+	}
+	this.written = append(this.written, dataset.points...)
+	this.writes++
+	return nil
+}
+
+// values returns the "v" field of the points written
+func (this *testClient) values() []influxdb.Value {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	values := make([]influxdb.Value, len(this.written))
+	for i, point := range this.written {
+		values[i] = point.Fields["v"]
+	}
+	return values
+}
+
+func testWriter(t *testing.T, config Config) *Writer {
+	if config.Interval == 0 {
+		config.Interval = time.Hour
+	}
+	if writer, err := gopi.Open(config, gopi.NullLogger()); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return writer.(*Writer)
+	}
+}
+
+func testPoint(name string, value int) *influxdb.Point {
+	return &influxdb.Point{Name: name, Fields: map[string]influxdb.Value{"v": value}}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func TestWriter_000(t *testing.T) {
+	client := new(testClient)
+	tests := []Config{
+		{},
+		{Client: client, BatchSize: 10, BufferSize: 5},
+		{Client: client, Interval: -time.Second},
+		{Client: client, Overflow: Overflow(99)},
+	}
+	for i, config := range tests {
+		if _, err := gopi.Open(config, gopi.NullLogger()); err != influxdb.ErrBadParameter {
+			t.Errorf("Test %v: Expected ErrBadParameter, got %v", i, err)
+		}
+	}
+}
+
+func TestWriter_001(t *testing.T) {
+	client := new(testClient)
+	writer := testWriter(t, Config{Client: client, BatchSize: 100, BufferSize: 100})
+	defer writer.Close()
+
+	for _, point := range []*influxdb.Point{nil, {Fields: map[string]influxdb.Value{"v": 1}}, {Name: "cpu"}} {
+		if err := writer.Add(point); err != influxdb.ErrBadParameter {
+			t.Errorf("Point %v: Expected ErrBadParameter, got %v", point, err)
+		}
+	}
+
+	// Points are grouped into one dataset for each series
+	ts := time.Unix(1500000000, 0)
+	writer.Add(testPoint("cpu", 1), testPoint("mem", 2), testPoint("cpu", 3))
+	writer.Add(&influxdb.Point{Name: "cpu", Tags: map[string]string{"host": "pi"}, Fields: map[string]influxdb.Value{"v": 4}, Time: ts})
+	if writer.Len() != 4 {
+		t.Errorf("Unexpected length: %v", writer.Len())
+	} else if err := writer.Flush(); err != nil {
+		t.Error(err)
+	} else if writer.Len() != 0 {
+		t.Errorf("Unexpected length after flush: %v", writer.Len())
+	} else if values := client.values(); len(values) != 4 || values[0] != 1 || values[1] != 3 || values[2] != 2 || values[3] != 4 {
+		t.Errorf("Unexpected values: %v", values)
+	} else if client.writes != 3 {
+		t.Errorf("Unexpected number of writes: %v", client.writes)
+	} else if client.written[0].Time.IsZero() || client.written[3].Time.Equal(ts) == false || client.written[3].Tags["host"] != "pi" {
+		t.Errorf("Unexpected points: %v", client.written)
+	}
+}
+
+func TestWriter_002(t *testing.T) {
+	client := new(testClient)
+	writer := testWriter(t, Config{Client: client, BatchSize: 5, BufferSize: 5, Overflow: OVERFLOW_DROP_OLDEST})
+	defer writer.Close()
+
+	// Prevent the background task from writing while the buffer fills
+	writer.write.Lock()
+	for i := 0; i < 8; i++ {
+		if err := writer.Add(testPoint("cpu", i)); err != nil {
+			t.Error(err)
+		}
+	}
+	writer.write.Unlock()
+
+	if writer.Dropped() != 3 {
+		t.Errorf("Unexpected dropped: %v", writer.Dropped())
+	} else if err := writer.Flush(); err != nil {
+		t.Error(err)
+	} else if values := client.values(); len(values) != 5 || values[0] != 3 || values[4] != 7 {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestWriter_003(t *testing.T) {
+	client := new(testClient)
+	writer := testWriter(t, Config{Client: client, BatchSize: 5, BufferSize: 5, Overflow: OVERFLOW_DROP_NEWEST})
+	defer writer.Close()
+
+	writer.write.Lock()
+	for i := 0; i < 8; i++ {
+		if err := writer.Add(testPoint("cpu", i)); err != nil {
+			t.Error(err)
+		}
+	}
+	writer.write.Unlock()
+
+	if writer.Dropped() != 3 {
+		t.Errorf("Unexpected dropped: %v", writer.Dropped())
+	} else if err := writer.Flush(); err != nil {
+		t.Error(err)
+	} else if values := client.values(); len(values) != 5 || values[0] != 0 || values[4] != 4 {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestWriter_004(t *testing.T) {
+	client := new(testClient)
+	writer := testWriter(t, Config{Client: client, BatchSize: 5, BufferSize: 5, Overflow: OVERFLOW_BLOCK})
+	defer writer.Close()
+
+	// Add blocks when the buffer is full, until the background task
+	// writes a batch
+	writer.write.Lock()
+	for i := 0; i < 5; i++ {
+		writer.Add(testPoint("cpu", i))
+	}
+	done := make(chan error)
+	go func() {
+		done <- writer.Add(testPoint("cpu", 5))
+	}()
+	select {
+	case <-done:
+		t.Fatal("Expected Add to block when the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+		writer.write.Unlock()
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Add to return after the buffer was written")
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Error(err)
+	} else if values := client.values(); len(values) != 6 || values[5] != 5 {
+		t.Errorf("Unexpected values: %v", values)
+	} else if writer.Dropped() != 0 {
+		t.Errorf("Unexpected dropped: %v", writer.Dropped())
+	}
+}
+
+func TestWriter_005(t *testing.T) {
+	client := new(testClient)
+	writer := testWriter(t, Config{Client: client})
+
+	// Close writes remaining points, and Add then fails
+	writer.Add(testPoint("cpu", 1), testPoint("cpu", 2))
+	if err := writer.Close(); err != nil {
+		t.Error(err)
+	} else if values := client.values(); len(values) != 2 {
+		t.Errorf("Unexpected values: %v", values)
+	} else if err := writer.Add(testPoint("cpu", 3)); err != influxdb.ErrNotConnected {
+		t.Errorf("Expected ErrNotConnected, got %v", err)
+	} else if err := writer.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWriter_006(t *testing.T) {
+	client := &testClient{fail: "bad"}
+	failed := make([]*influxdb.Point, 0)
+	writer := testWriter(t, Config{Client: client, ErrorHandler: func(points []*influxdb.Point, err error) {
+		failed = append(failed, points...)
+	}})
+	defer writer.Close()
+
+	// Only the points which were not written are passed to the handler,
+	// and groups after the failed group are still written
+	writer.Add(testPoint("cpu", 1), testPoint("bad", 2), testPoint("mem", 3), testPoint("bad", 4))
+	if err := writer.Flush(); err == nil {
+		t.Error("Expected error from Flush")
+	} else if values := client.values(); len(values) != 2 || values[0] != 1 || values[1] != 3 {
+		t.Errorf("Unexpected values: %v", values)
+	} else if len(failed) != 2 || failed[0].Fields["v"] != 2 || failed[1].Fields["v"] != 4 {
+		t.Errorf("Unexpected failed points: %v", failed)
+	}
+}